@@ -0,0 +1,184 @@
+package zerologger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IPMode selects how client addresses are logged.
+type IPMode int
+
+const (
+	// IPKeep logs addresses as they are.
+	IPKeep IPMode = iota
+
+	// IPTruncate zeroes the host part of addresses, keeping the /24 network
+	// of IPv4 and the /48 network of IPv6 addresses.
+	IPTruncate
+
+	// IPHash replaces addresses with a keyed HMAC, so requests from the same
+	// client can be linked for as long as the key is not rotated.
+	IPHash
+
+	// IPDrop omits fields carrying addresses from the log.
+	IPDrop
+)
+
+// ipAnonymizer rewrites the addresses in logged values. A nil anonymizer
+// leaves every value untouched.
+type ipAnonymizer struct {
+	mode    IPMode
+	key     func() []byte
+	headers map[string]bool
+}
+
+// newIPAnonymizer returns the anonymizer of the config, or nil for IPKeep.
+func newIPAnonymizer(cfg *Config) *ipAnonymizer {
+	if cfg.AnonymizeIP == IPKeep {
+		return nil
+	}
+
+	a := &ipAnonymizer{mode: cfg.AnonymizeIP, key: cfg.IPHashKey, headers: map[string]bool{}}
+	if a.mode == IPHash && a.key == nil {
+		key := make([]byte, 32)
+		randomBytes(key)
+		a.key = func() []byte { return key }
+	}
+	for _, header := range cfg.IPHeaders {
+		a.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	return a
+}
+
+// carries reports whether tag logs a client address, so that it must be
+// anonymized.
+func (a *ipAnonymizer) carries(cfg *Config, tag string) bool {
+	if a == nil {
+		return false
+	}
+	if tag == TagIP || tag == TagIPs {
+		return true
+	}
+	header := tagHeader(cfg, tag)
+	return header != "" && a.headers[header]
+}
+
+// ipValue returns a function reading the addresses logged by tag.
+func ipValue(cfg *Config, tag string) func(c Context) string {
+	if tag == TagIP {
+		return func(c Context) string {
+			return c.RealIP()
+		}
+	}
+	header := tagHeader(cfg, tag)
+	return func(c Context) string {
+		return c.Header(header)
+	}
+}
+
+// anonymize rewrites every address in a value such as "192.0.2.1", an
+// X-Forwarded-For list, or a Forwarded header. Elements that are not
+// addresses, like "unknown" or obfuscated identifiers, are kept.
+func (a *ipAnonymizer) anonymize(v string) string {
+	if v == "" {
+		return v
+	}
+
+	parts := strings.Split(v, ",")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if strings.Contains(part, "=") {
+			parts[i] = a.forwarded(part)
+		} else if addr, ok := a.addr(part); ok {
+			parts[i] = addr
+		} else {
+			parts[i] = part
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// forwarded rewrites the "for" and "by" parameters of one element of a
+// Forwarded header (RFC 7239).
+func (a *ipAnonymizer) forwarded(element string) string {
+	pairs := strings.Split(element, ";")
+	for i, pair := range pairs {
+		eq := strings.IndexByte(pair, '=')
+		if eq < 0 {
+			continue
+		}
+		if name := strings.ToLower(strings.TrimSpace(pair[:eq])); name != "for" && name != "by" {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(pair[eq+1:]), `"`)
+		value = strings.TrimPrefix(value, "[")
+		if j := strings.IndexByte(value, ']'); j >= 0 {
+			value = value[:j]
+		}
+		addr, ok := a.addr(value)
+		if !ok {
+			continue
+		}
+		if strings.Contains(addr, ":") {
+			addr = `"[` + addr + `]"`
+		}
+		pairs[i] = pair[:eq+1] + addr
+	}
+	return strings.Join(pairs, ";")
+}
+
+// addr anonymizes a single address, with or without a port. The port is
+// removed. It returns false if s is not an address.
+func (a *ipAnonymizer) addr(s string) (string, bool) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		host, _, err := net.SplitHostPort(s)
+		if err != nil {
+			return "", false
+		}
+		if ip = net.ParseIP(host); ip == nil {
+			return "", false
+		}
+	}
+
+	switch a.mode {
+	case IPHash:
+		mac := hmac.New(sha256.New, a.key())
+		mac.Write([]byte(ip.String()))
+		return hex.EncodeToString(mac.Sum(nil)[:8]), true
+	default:
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(24, 32)).String(), true
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String(), true
+	}
+}
+
+// RotatingKey returns a key function for Config.IPHashKey or
+// Config.RedactHashKey that replaces its random key every interval, so that
+// hashed values cannot be linked across periods. Old keys are discarded, so hashes cannot be reversed once
+// the key has been rotated.
+func RotatingKey(clock Clock, interval time.Duration) func() []byte {
+	var (
+		mu      sync.Mutex
+		key     []byte
+		expires time.Time
+	)
+	return func() []byte {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if now := clock.Now(); key == nil || !now.Before(expires) {
+			key = make([]byte, 32)
+			randomBytes(key)
+			expires = now.Truncate(interval).Add(interval)
+		}
+		return key
+	}
+}
@@ -0,0 +1,101 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+func testAnonymizeIP(t *testing.T, cfg Config, header http.Header) string {
+	buf := new(bytes.Buffer)
+	cfg.Output = buf
+	e := echo.New()
+	e.Use(New(cfg))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+	return buf.String()
+}
+
+func Test_AnonymizeIP_Truncate(t *testing.T) {
+	out := testAnonymizeIP(t, Config{
+		Format:      []string{TagIP, TagIPs, "header:Forwarded", "header:X-Real-Ip>real"},
+		AnonymizeIP: IPTruncate,
+	}, http.Header{
+		"X-Forwarded-For": {"2001:db8:cafe:1::17, 198.51.100.7, unknown"},
+		"Forwarded":       {`for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`},
+		"X-Real-Ip":       {"203.0.113.195:8080"},
+	})
+
+	require.Contains(t, out, `"ip":"2001:db8:cafe::",`+
+		`"ips":"2001:db8:cafe::, 198.51.100.0, unknown",`+
+		`"Forwarded":"for=192.0.2.0;proto=http, for=\"[2001:db8:cafe::]\"",`+
+		`"real":"203.0.113.0"`)
+}
+
+func Test_AnonymizeIP_Hash(t *testing.T) {
+	key := func() []byte { return []byte("key") }
+	cfg := Config{Format: []string{TagIP}, ContextFormat: []string{}, AnonymizeIP: IPHash, IPHashKey: key}
+
+	hashed := regexp.MustCompile(`"ip":"[0-9a-f]{16}"`)
+	out := testAnonymizeIP(t, cfg, nil)
+	require.Regexp(t, hashed, out)
+	require.NotContains(t, out, "192.0.2")
+	require.Equal(t, hashed.FindString(out), hashed.FindString(testAnonymizeIP(t, cfg, nil)))
+}
+
+func Test_AnonymizeIP_HashNoKey(t *testing.T) {
+	// New does not validate the config, so it must not crash without a key
+	out := testAnonymizeIP(t, Config{Format: []string{TagIP}, AnonymizeIP: IPHash}, nil)
+	require.Regexp(t, `"ip":"[0-9a-f]{16}"`, out)
+	require.NotContains(t, out, "192.0.2")
+}
+
+func Test_AnonymizeIP_Drop(t *testing.T) {
+	out := testAnonymizeIP(t, Config{
+		Format:      []string{TagIP, TagIPs, TagMethod},
+		AnonymizeIP: IPDrop,
+	}, http.Header{"X-Forwarded-For": {"198.51.100.7"}})
+
+	require.NotContains(t, out, `"ip"`)
+	require.NotContains(t, out, `"ips"`)
+	require.Contains(t, out, `"method":"GET"`)
+}
+
+func Test_AnonymizeIP_Validate(t *testing.T) {
+	require.Error(t, Config{AnonymizeIP: IPHash}.Validate())
+	require.Error(t, Config{AnonymizeIP: IPMode(42)}.Validate())
+	require.NoError(t, Config{AnonymizeIP: IPTruncate}.Validate())
+}
+
+func Test_RotatingKey(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Date(2021, time.November, 27, 0, 0, 0, 0, time.UTC))
+	key := RotatingKey(clock, 24*time.Hour)
+
+	first := key()
+	require.Len(t, first, 32)
+
+	clock.Add(23 * time.Hour)
+	require.Equal(t, first, key())
+
+	clock.Add(time.Hour)
+	require.NotEqual(t, first, key())
+}
@@ -0,0 +1,195 @@
+package zerologger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// bodyBuffer keeps the first limit bytes written to it and silently drops
+// the rest, remembering that it did so.
+type bodyBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func newBodyBuffer(limit int) *bodyBuffer {
+	return &bodyBuffer{limit: limit}
+}
+
+// Write never fails, so it can be used with io.TeeReader style plumbing
+// without ever interrupting the body it is copying from.
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - b.buf.Len(); n < len(p) {
+		b.truncated = true
+		if n > 0 {
+			b.buf.Write(p[:n])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// log adds the captured body to the event. A complete JSON object or array
+// is nested as raw JSON, anything else is logged as a string with the
+// truncation marker appended when the body did not fit.
+//
+// With redaction, JSON bodies are redacted by path and form bodies by name.
+// A JSON body that cannot be parsed, because it is truncated or invalid,
+// is masked completely since the paths cannot be found in it. Personal data
+// is scrubbed last.
+func (b *bodyBuffer) log(event *zerolog.Event, key string, cfg *Config, contentType string) *zerolog.Event {
+	data := b.buf.Bytes()
+	if r := cfg.redactor; r != nil {
+		switch {
+		case r.paths && looksLikeJSON(data):
+			if b.truncated {
+				return event.Str(key, RedactedValue)
+			}
+			redacted, ok := r.json(data)
+			if !ok {
+				return event.Str(key, RedactedValue)
+			}
+			data = redacted
+		case r.names && allowedContentType(contentType, []string{echo.MIMEApplicationForm}):
+			data = []byte(r.query(string(data)))
+		}
+	}
+	if cfg.Scrubber != nil {
+		data = []byte(cfg.Scrubber.Scrub(string(data)))
+	}
+	if !b.truncated && looksLikeJSON(data) {
+		// Compact also validates, and keeps pretty printed bodies on one line
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err == nil {
+			return event.RawJSON(key, compact.Bytes())
+		}
+	}
+	if b.truncated {
+		return event.Str(key, string(data)+cfg.BodyTruncated)
+	}
+	return event.Str(key, string(data))
+}
+
+// looksLikeJSON reports whether data starts like a JSON object or array.
+func looksLikeJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// bodyReader mirrors everything read from the wrapped body into a capture
+// buffer, so the handler still sees the complete body.
+type bodyReader struct {
+	io.ReadCloser
+	capture *bodyBuffer
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.capture.Write(p[:n])
+	}
+	return n, err
+}
+
+// fill reads whatever the handler left unread, up to the capture limit, so
+// the log does not depend on whether the handler consumed the body.
+func (r *bodyReader) fill() {
+	if r.capture.truncated {
+		return
+	}
+	io.CopyN(io.Discard, r, int64(r.capture.limit-r.capture.buf.Len()+1))
+}
+
+// allowedContentType reports whether the media type in contentType matches
+// one of the allowed prefixes.
+func allowedContentType(contentType string, allowed []string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range allowed {
+		if strings.HasPrefix(mediaType, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyWriter mirrors everything written to the wrapped response into a
+// capture buffer. Capturing stops for good once the response is flushed,
+// since streamed responses are not meaningful to log.
+type bodyWriter struct {
+	http.ResponseWriter
+	capture      *bodyBuffer
+	contentTypes []string
+	checked      bool
+	skip         bool
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	if w.check() {
+		w.capture.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// check decides on the first write whether the content type of the
+// response is captured, and reports whether it is.
+func (w *bodyWriter) check() bool {
+	if !w.checked {
+		w.checked = true
+		w.skip = !allowedContentType(w.Header().Get(echo.HeaderContentType), w.contentTypes)
+	}
+	return !w.skip
+}
+
+// Flush implements the http.Flusher interface.
+func (w *bodyWriter) Flush() {
+	w.checked = true
+	w.skip = true
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *bodyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.checked = true
+	w.skip = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Push implements the http.Pusher interface.
+func (w *bodyWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (w *bodyWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.check() {
+		r = io.TeeReader(r, w.capture)
+	}
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// captured reports whether the response body should be logged.
+func (w *bodyWriter) captured() bool {
+	return w.checked && !w.skip
+}
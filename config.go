@@ -1,9 +1,14 @@
 package zerologger
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -21,8 +26,88 @@ type Config struct {
 	// Format defines the logging tags
 	//
 	// Optional. Default: []string{TagTime, TagStatus, TagLatency, TagMethod, TagPath}
+	// or, for ProfileGCP, every tag of the httpRequest object and the trace.
 	Format []string
 
+	// Profile selects the names and layout of the fields of the built-in
+	// tags.
+	//
+	// Optional. Default: ProfileFlat
+	Profile Profile
+
+	// FieldNames renames the fields of tags, e.g. {TagUA: "user_agent"}. A
+	// tag in Format can also be renamed with an alias, so that
+	// "header:X-Tenant-ID>tenant" logs the header as "tenant".
+	//
+	// Optional. Default: nil
+	FieldNames map[string]string
+
+	// FieldPrefix is prepended to the key of every built-in field, e.g.
+	// "http." logs TagStatus as "http.status".
+	//
+	// Optional. Default: ""
+	FieldPrefix string
+
+	// FieldGroup nests every field of Format in an object with this name.
+	//
+	// Optional. Default: ""
+	FieldGroup string
+
+	// ProjectID is the Google Cloud project used by ProfileGCP to link logs
+	// to traces.
+	//
+	// Optional. Default: ""
+	ProjectID string
+
+	// RouteResolver returns the route pattern of a request for TagRoute with
+	// NewHTTP, e.g. chi.RouteContext(r.Context()).RoutePattern(). It is called
+	// before and after the handler, since routers may only know the route
+	// after routing. New ignores it and uses the route of Echo.
+	//
+	// Optional. Default: nil, the route is empty
+	RouteResolver func(r *http.Request) string
+
+	// ContextFormat defines the fields of the per-request logger returned by
+	// FromContext, see EnableContextLogger. Only tags known before the
+	// handler runs can be used, that is TagReferer, TagProtocol, TagID,
+	// TagIP, TagIPs, TagHost, TagMethod, TagPath, TagURL, TagUA, TagRoute,
+	// the trace tags and TagHeader.
+	//
+	// Optional. Default: []string{TagID, TagRoute, TagIP}
+	ContextFormat []string
+
+	// EnableContextLogger creates the per-request logger. Without it,
+	// FromContext returns the logger of the request context or the global
+	// logger, and warns once that the request fields are missing.
+	//
+	// Optional. Default: false
+	EnableContextLogger bool
+
+	// RequestIDGenerator creates an ID for requests without one. The ID is set
+	// on the request and response headers, logged by TagID and returned by
+	// RequestID. See UUIDv4, UUIDv7 and ULID for ready-made generators.
+	//
+	// Optional. Default: nil, IDs are only read from the request
+	RequestIDGenerator func() string
+
+	// RequestIDHeader is the header that carries the request ID.
+	//
+	// Optional. Default: echo.HeaderXRequestID
+	RequestIDHeader string
+
+	// IgnoreClientRequestID always generates a new ID, replacing any ID sent
+	// by the client. It requires RequestIDGenerator.
+	//
+	// Optional. Default: false
+	IgnoreClientRequestID bool
+
+	// GenerateSpanID creates a new span ID for this hop. The span of the
+	// traceparent header becomes the parent span, and a new trace is started
+	// if the request has none. The new traceparent is set on the response.
+	//
+	// Optional. Default: false
+	GenerateSpanID bool
+
 	// TimeZone can be specified, such as "UTC" and "America/New_York" and "Asia/Chongqing", etc
 	//
 	// Optional. Default: "Local"
@@ -38,6 +123,11 @@ type Config struct {
 	// Optional. Default: 500 * time.Millisecond, Minimum: 500 * time.Millisecond
 	TimeInterval time.Duration
 
+	// Clock is the source of time for TagTime and TagLatency.
+	//
+	// Optional. Default: SystemClock
+	Clock Clock
+
 	// Output is an io.Writer where logs can be written. Zerologger will copy
 	// the global Logger if Output is not set. Typically used in tests.
 	//
@@ -49,11 +139,258 @@ type Config struct {
 	// Optional. Default: false
 	PrettyLatency bool
 
+	// LevelFunc decides the level of each request log. See SuccessLevel,
+	// NotFoundAsInfo and CanceledAsInfo for ready-made policies.
+	//
+	// Optional. Default: DefaultLevel
+	LevelFunc LevelFunc
+
+	// Sampler decides which requests are logged. Requests that return an
+	// error, respond with a 5xx status or are slow (see SlowThreshold) are
+	// always logged. The logs of sampled requests have a SampleRateFieldName
+	// field.
+	//
+	// Optional. Default: nil, every request is logged
+	Sampler Sampler
+
+	// SlowThreshold is the latency from which a request is slow. Slow
+	// requests are logged at least at Warn level, with a SlowFieldName field
+	// and the extra tags of SlowFormat.
+	//
+	// Optional. Default: 0, no request is slow
+	SlowThreshold time.Duration
+
+	// SlowRoutes overrides SlowThreshold for routes, by their pattern such as
+	// "/users/:id". A threshold of 0 disables slow detection for the route.
+	//
+	// Optional. Default: nil
+	SlowRoutes map[string]time.Duration
+
+	// SlowFormat lists tags only logged for slow requests, in addition to
+	// Format, e.g. TagGoroutines, TagBody or "header:X-Tenant".
+	//
+	// Optional. Default: nil
+	SlowFormat []string
+
+	// Recover recovers from panics in handlers, like the Recover middleware
+	// of Echo. The panic is turned into an error for the HTTP error handler,
+	// and logged with all tags plus the PanicFieldName and StackFieldName
+	// fields.
+	//
+	// Optional. Default: false
+	Recover bool
+
+	// RecoverStackSize is the maximum size of the logged stack trace.
+	//
+	// Optional. Default: 4 KB
+	RecoverStackSize int
+
+	// RePanic panics again with the recovered value once it is logged.
+	//
+	// Optional. Default: false
+	RePanic bool
+
+	// BodyLimit is the maximum number of bytes of the body logged by TagBody
+	// and TagResBody. The handler and the client still see the complete body.
+	//
+	// Optional. Default: 1024
+	BodyLimit int
+
+	// BodyContentTypes lists the media types whose bodies are logged by
+	// TagBody and TagResBody. An entry matches every media type it prefixes, so "text/"
+	// matches "text/plain" and "text/html".
+	//
+	// Optional. Default: []string{"application/json", "application/xml", "application/x-www-form-urlencoded", "text/"}
+	BodyContentTypes []string
+
+	// BodyTruncated is appended to a logged body that exceeded BodyLimit.
+	//
+	// Optional. Default: "..."
+	BodyTruncated string
+
+	// Redact hides sensitive values from every tag: headers, query
+	// parameters, cookies, form fields, locals and the fields of Set by name,
+	// and bodies by JSON path. The name=value pairs of error messages are
+	// redacted too. The first matching rule wins.
+	//
+	// Optional. Default: nil
+	Redact []RedactRule
+
+	// RedactHashKey returns the HMAC key used by RedactHash. See RotatingKey.
+	//
+	// Optional. Default: a random key that lives as long as the process
+	RedactHashKey func() []byte
+
+	// Scrubber replaces personal data, such as email addresses and card
+	// numbers, in the logged URL, path, query, referer, user agent, headers,
+	// cookies, form fields, locals, bodies, error message and the strings of
+	// Set.
+	//
+	// Optional. Default: nil
+	Scrubber *Scrubber
+
+	// AnonymizeIP hides client addresses in TagIP, TagIPs and the headers of
+	// IPHeaders, by truncating, hashing or dropping them.
+	//
+	// Optional. Default: IPKeep
+	AnonymizeIP IPMode
+
+	// IPHashKey returns the HMAC key used by IPHash. See RotatingKey.
+	//
+	// Required with IPHash. New, which does not validate the config, falls
+	// back to a random key that lives as long as the middleware.
+	IPHashKey func() []byte
+
+	// IPHeaders lists the headers carrying client addresses, which are
+	// anonymized when logged with a "header:" tag.
+	//
+	// Optional. Default: []string{"X-Forwarded-For", "X-Real-Ip", "Forwarded", "True-Client-Ip", "X-Client-Ip"}
+	IPHeaders []string
+
 	enableLatency    bool
+	enableBody       bool
+	enableTrace      bool
+	enableResBody    bool
 	timeZoneLocation *time.Location
+	redactor         *redactor
+	ipAnonymizer     *ipAnonymizer
 	logger           zerolog.Logger
 }
 
+// ErrInvalidConfig is wrapped by the error returned from Config.Validate.
+var ErrInvalidConfig = errors.New("zerologger: invalid config")
+
+// Validate reports every problem in the config, such as unknown tags in
+// Format or a TimeZone that cannot be loaded. Zero values are valid and
+// replaced by their defaults when the middleware is created.
+func (cfg Config) Validate() error {
+	var problems []string
+
+	for _, tag := range cfg.Format {
+		if err := checkTag(tag); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, tag := range cfg.SlowFormat {
+		if err := checkTag(tag); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, tag := range cfg.ContextFormat {
+		if err := checkContextTag(tag); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if cfg.Profile == ProfileGCP && cfg.ProjectID == "" && (cfg.Format == nil || containsTag(cfg.Format, TagTraceID)) {
+		problems = append(problems, "the GCP profile needs a project ID to log the trace")
+	}
+
+	if cfg.IgnoreClientRequestID && cfg.RequestIDGenerator == nil {
+		problems = append(problems, "ignoring client request IDs requires a request ID generator")
+	}
+
+	if cfg.RePanic && !cfg.Recover {
+		problems = append(problems, "panicking again requires recovering")
+	}
+
+	if cfg.TimeZone != "" {
+		if _, err := time.LoadLocation(cfg.TimeZone); err != nil {
+			problems = append(problems, fmt.Sprintf("time zone %q: %v", cfg.TimeZone, err))
+		}
+	}
+
+	if cfg.TimeFormat != "" {
+		if err := checkTimeFormat(cfg.TimeFormat); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for i, rule := range cfg.Redact {
+		if err := rule.check(); err != nil {
+			problems = append(problems, fmt.Sprintf("redact rule %d: %v", i, err))
+		}
+	}
+
+	if cfg.AnonymizeIP < IPKeep || cfg.AnonymizeIP > IPDrop {
+		problems = append(problems, fmt.Sprintf("unknown IP mode %d", cfg.AnonymizeIP))
+	}
+	if cfg.AnonymizeIP == IPHash && cfg.IPHashKey == nil {
+		problems = append(problems, "hashing IP addresses requires a key")
+	}
+
+	if cfg.TimeInterval < 0 {
+		problems = append(problems, fmt.Sprintf("negative time interval %s", cfg.TimeInterval))
+	}
+	if cfg.SlowThreshold < 0 {
+		problems = append(problems, fmt.Sprintf("negative slow threshold %s", cfg.SlowThreshold))
+	}
+	for route, threshold := range cfg.SlowRoutes {
+		if threshold < 0 {
+			problems = append(problems, fmt.Sprintf("negative slow threshold %s for route %q", threshold, route))
+		}
+	}
+	if cfg.RecoverStackSize < 0 {
+		problems = append(problems, fmt.Sprintf("negative stack size %d", cfg.RecoverStackSize))
+	}
+	if cfg.BodyLimit < 0 {
+		problems = append(problems, fmt.Sprintf("negative body limit %d", cfg.BodyLimit))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// containsTag reports whether the format holds tag, with or without alias.
+func containsTag(format []string, tag string) bool {
+	for _, entry := range format {
+		if t, _ := splitAlias(entry); t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFormat returns the entries of format followed by those of extra that
+// format does not contain, so no field is written twice.
+func mergeFormat(format, extra []string) []string {
+	merged := append([]string{}, format...)
+	for _, entry := range extra {
+		if !containsEntry(merged, entry) {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// containsEntry reports whether format contains entry, alias included.
+func containsEntry(format []string, entry string) bool {
+	for _, e := range format {
+		if e == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTimeFormat returns an error if layout has no time elements or cannot
+// parse its own output.
+func checkTimeFormat(layout string) error {
+	ref := time.Date(2021, time.November, 27, 22, 48, 39, 123456789, time.UTC)
+	formatted := ref.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("time format %q has no time elements", layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return fmt.Errorf("time format %q: %v", layout, err)
+	}
+	return nil
+}
+
 // Helper function to set default values
 func setConfig(config ...Config) (cfg Config) {
 	if len(config) > 0 {
@@ -66,9 +403,19 @@ func setConfig(config ...Config) (cfg Config) {
 	}
 
 	if cfg.Format == nil {
-		cfg.Format = []string{TagTime, TagStatus, TagLatency, TagMethod, TagPath}
+		cfg.Format = cfg.Profile.defaultFormat()
 	}
 
+	if cfg.ContextFormat == nil {
+		cfg.ContextFormat = []string{TagID, TagRoute, TagIP}
+	}
+
+	// Header lookups with a canonical name do not allocate
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = echo.HeaderXRequestID
+	}
+	cfg.RequestIDHeader = http.CanonicalHeaderKey(cfg.RequestIDHeader)
+
 	if cfg.TimeZone == "" {
 		cfg.TimeZone = "Local"
 	}
@@ -79,6 +426,46 @@ func setConfig(config ...Config) (cfg Config) {
 		cfg.TimeInterval = 500 * time.Millisecond
 	}
 
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock
+	}
+
+	if cfg.LevelFunc == nil {
+		cfg.LevelFunc = DefaultLevel
+	}
+
+	if cfg.RecoverStackSize <= 0 {
+		cfg.RecoverStackSize = 4 << 10
+	}
+
+	if cfg.BodyLimit <= 0 {
+		cfg.BodyLimit = 1024
+	}
+	if cfg.BodyContentTypes == nil {
+		cfg.BodyContentTypes = []string{
+			echo.MIMEApplicationJSON,
+			echo.MIMEApplicationXML,
+			echo.MIMEApplicationForm,
+			"text/",
+		}
+	}
+	if cfg.BodyTruncated == "" {
+		cfg.BodyTruncated = "..."
+	}
+
+	if cfg.IPHeaders == nil {
+		cfg.IPHeaders = []string{
+			echo.HeaderXForwardedFor,
+			echo.HeaderXRealIP,
+			"Forwarded",
+			"True-Client-Ip",
+			"X-Client-Ip",
+		}
+	}
+
+	cfg.redactor = newRedactor(cfg.Redact, cfg.RedactHashKey)
+	cfg.ipAnonymizer = newIPAnonymizer(&cfg)
+
 	cfg.logger = log.Logger
 	if cfg.Output != nil {
 		cfg.logger = log.Logger.Output(cfg.Output)
@@ -0,0 +1,52 @@
+package zerologger_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_Validate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{
+		Format:     []string{TagTime, TagStatus, TagHeader + "X-Test", TagLocals + "test"},
+		TimeZone:   "UTC",
+		TimeFormat: "Monday 15:04",
+	}.Validate())
+
+	tests := []struct {
+		name string
+		cfg  Config
+		msg  string
+	}{
+		{"UnknownTag", Config{Format: []string{"latncy"}}, `unknown tag "latncy"`},
+		{"EmptyPrefix", Config{Format: []string{TagHeader}}, `tag "header:" has no name`},
+		{"TimeZone", Config{TimeZone: "invalid"}, `time zone "invalid"`},
+		{"TimeFormat", Config{TimeFormat: "time"}, `time format "time" has no time elements`},
+		{"TimeInterval", Config{TimeInterval: -1}, "negative time interval"},
+		{"BodyLimit", Config{BodyLimit: -1}, "negative body limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrInvalidConfig))
+			require.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+func Test_NewE(t *testing.T) {
+	m, err := NewE()
+	require.NoError(t, err)
+	require.NotNil(t, m)
+
+	m, err = NewE(Config{Format: []string{"latncy"}, TimeZone: "invalid"})
+	require.Error(t, err)
+	require.Nil(t, m)
+	require.Contains(t, err.Error(), `unknown tag "latncy"; time zone "invalid"`)
+}
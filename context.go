@@ -0,0 +1,120 @@
+package zerologger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// loggerKey is the request store key of the per-request logger.
+const loggerKey = "zerologger.logger"
+
+// contextWriter adds one field to the per-request logger.
+type contextWriter func(c Context, zc zerolog.Context) zerolog.Context
+
+// contextLoggerOff is set once a middleware is created without
+// Config.EnableContextLogger, so FromContext can tell why it has no logger.
+var contextLoggerOff int32
+
+// warnContextLoggerOff makes FromContext warn only once.
+var warnContextLoggerOff sync.Once
+
+// FromContext returns the per-request logger created by the middleware,
+// which carries the fields of Config.ContextFormat. Outside of the
+// middleware it falls back to the logger of the request context, and then
+// to the global logger. If a middleware has Config.EnableContextLogger off,
+// the first fall back to the global logger logs a warning.
+func FromContext(ctx echo.Context) *zerolog.Logger {
+	if l, ok := ctx.Get(loggerKey).(*zerolog.Logger); ok {
+		return l
+	}
+	if l := zerolog.Ctx(ctx.Request().Context()); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	if atomic.LoadInt32(&contextLoggerOff) != 0 {
+		warnContextLoggerOff.Do(func() {
+			log.Warn().Msg("zerologger: FromContext returns the global logger, without the request fields, since EnableContextLogger is off")
+		})
+	}
+	return &log.Logger
+}
+
+// attachLogger creates the per-request logger and stores it for the request
+// and in the request context, so zerolog.Ctx works as well. The Logger of
+// Install gets the same fields, on top of its own output and level.
+func attachLogger(c Adapter, logger zerolog.Logger, writers []contextWriter) {
+	fields := func(zc zerolog.Context) zerolog.Context {
+		for _, w := range writers {
+			zc = w(c, zc)
+		}
+		return zc
+	}
+	l := fields(logger.With()).Logger()
+
+	c.Set(loggerKey, &l)
+	c.SetContext(l.WithContext(c.Context()))
+
+	// With Install, c.Logger() carries the request fields too
+	if ec, ok := c.(*echoContext); ok {
+		if el, ok := ec.ctx.Echo().Logger.(*Logger); ok {
+			ec.ctx.SetLogger(el.with(fields))
+		}
+	}
+}
+
+// compileContextFormat parses cfg.ContextFormat into context writers.
+// Tags that are unknown or only known after the handler ran are ignored.
+func compileContextFormat(cfg *Config) []contextWriter {
+	writers := make([]contextWriter, 0, len(cfg.ContextFormat))
+	for _, entry := range cfg.ContextFormat {
+		if w := compileContextTag(cfg, entry); w != nil {
+			writers = append(writers, w)
+		}
+	}
+	return writers
+}
+
+// compileContextTag returns the context writer for a single tag, or nil if
+// the tag cannot be used in Config.ContextFormat. It logs the same values as
+// the access log.
+func compileContextTag(cfg *Config, entry string) contextWriter {
+	// Nested paths are written as dotted keys, which most backends expand
+	tag, spec := cfg.field(entry)
+	key := strings.Join(spec.path, ".")
+
+	value, ok := compileRequestValue(cfg, tag, spec)
+	if !ok || value == nil {
+		return nil
+	}
+	if spec.format == formatSampled {
+		return func(c Context, zc zerolog.Context) zerolog.Context {
+			if v, ok := value(c); ok {
+				return zc.Bool(key, v == "true")
+			}
+			return zc
+		}
+	}
+	return func(c Context, zc zerolog.Context) zerolog.Context {
+		if v, ok := value(c); ok {
+			return zc.Str(key, v)
+		}
+		return zc
+	}
+}
+
+// checkContextTag returns an error if tag cannot be used in
+// Config.ContextFormat.
+func checkContextTag(entry string) error {
+	if _, alias := splitAlias(entry); strings.Contains(entry, TagAlias) && alias == "" {
+		return fmt.Errorf("tag %q has no alias after %q", entry, TagAlias)
+	}
+	if compileContextTag(&Config{}, entry) == nil {
+		return fmt.Errorf("tag %q cannot be used in the context format", entry)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package zerologger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromContext_Off(t *testing.T) {
+	global := log.Logger
+	defer func() { log.Logger = global }()
+	warnContextLoggerOff = sync.Once{}
+
+	buf := new(bytes.Buffer)
+	log.Logger = zerolog.New(buf)
+
+	e := echo.New()
+	e.Use(New(Config{Format: []string{TagStatus}, Output: new(bytes.Buffer)}))
+	e.GET("/", func(c echo.Context) error {
+		FromContext(c).Info().Msg("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	require.Equal(t, 1, strings.Count(buf.String(), "EnableContextLogger is off"))
+	require.Equal(t, 2, strings.Count(buf.String(), `"message":"handler"`))
+}
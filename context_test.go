@@ -0,0 +1,131 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_FromContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:              []string{TagStatus},
+		ContextFormat:       []string{TagID, TagRoute, TagIP, TagHeader + "X-Tenant"},
+		EnableContextLogger: true,
+		Output:              buf,
+	}))
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		FromContext(c).Info().Msg("from echo")
+		zerolog.Ctx(c.Request().Context()).Info().Msg("from request")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(echo.HeaderXRequestID, "test")
+	req.Header.Set("X-Tenant", "acme")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines[:2] {
+		require.Contains(t, line, `"id":"test","route":"/users/:id","ip":"192.0.2.1","X-Tenant":"acme"`)
+	}
+	require.Contains(t, lines[0], `"message":"from echo"`)
+	require.Contains(t, lines[1], `"message":"from request"`)
+	require.NotContains(t, lines[2], `"route"`)
+}
+
+func Test_FromContext_Disabled(t *testing.T) {
+	e := echo.New()
+	e.Use(New(Config{}))
+
+	var logger *zerolog.Logger
+	e.GET("/", func(c echo.Context) error {
+		logger = FromContext(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, &log.Logger, logger)
+}
+
+func Test_ContextFormat_Validate(t *testing.T) {
+	err := Config{ContextFormat: []string{TagLatency}}.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `tag "latency" cannot be used in the context format`)
+}
+
+func Test_ContextFormat_Profiles(t *testing.T) {
+	tags := []string{
+		TagReferer, TagProtocol, TagID, TagIP, TagIPs, TagHost, TagMethod, TagPath, TagURL, TagUA,
+		TagRoute, TagTraceID, TagSpanID, TagTraceFlags, TagHeader + "X-Tenant",
+	}
+
+	for _, profile := range []Profile{ProfileFlat, ProfileGCP, ProfileECS, ProfileOTel} {
+		buf := new(bytes.Buffer)
+		e := echo.New()
+		e.Use(New(Config{
+			Profile:             profile,
+			Format:              tags,
+			ContextFormat:       tags,
+			EnableContextLogger: true,
+			Output:              buf,
+		}))
+		e.GET("/users/:id", func(c echo.Context) error {
+			FromContext(c).Info().Msg("handler")
+			return c.NoContent(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/users/42?a=1", nil)
+		req.Header.Set("Referer", "https://example.com/")
+		req.Header.Set("User-Agent", "test")
+		req.Header.Set("X-Tenant", "acme")
+		req.Header.Set(echo.HeaderXRequestID, "test")
+		req.Header.Set(echo.HeaderXForwardedFor, "192.0.2.1")
+		req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		handler, access := map[string]interface{}{}, map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &handler))
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &access))
+
+		// The context logger writes nested fields as dotted keys
+		flat := map[string]interface{}{}
+		flatten(flat, "", access)
+		for _, key := range []string{"level", "time", "message"} {
+			delete(handler, key)
+		}
+		require.NotEmpty(t, handler)
+		for key, v := range handler {
+			require.Equal(t, flat[key], v, "%s in profile %d", key, profile)
+		}
+	}
+}
+
+func flatten(flat map[string]interface{}, prefix string, m map[string]interface{}) {
+	for key, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(flat, prefix+key+".", nested)
+			continue
+		}
+		flat[prefix+key] = v
+	}
+}
@@ -0,0 +1,152 @@
+package zerologger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// fieldsKey is the request store key of the fields added by handlers.
+const fieldsKey = "zerologger.fields"
+
+// requestFields collects the fields handlers add to the access log.
+type requestFields struct {
+	mu    sync.Mutex
+	funcs []func(e *zerolog.Event, cfg *Config)
+	done  bool
+}
+
+// fieldsMu makes concurrent first calls of AddFields for a request share
+// the same requestFields. It is only taken while the list is created, so
+// requests that add no fields do not pay for it.
+var fieldsMu sync.Mutex
+
+// fieldsAdded is set once AddFields has been called, so the middleware only
+// looks up the fields of a request in processes that add any.
+var fieldsAdded int32
+
+// AddFields adds fields to the access log of the current request. The
+// function is called with the access log event once the request has been
+// handled, so one request produces a single wide log line. It is safe to
+// call AddFields from multiple goroutines. Outside of the middleware, or
+// once the request has been logged, the fields are not logged.
+func AddFields(ctx echo.Context, fn func(e *zerolog.Event)) {
+	addFields(ctx, func(e *zerolog.Event, _ *Config) {
+		fn(e)
+	})
+}
+
+// Set adds a single field to the access log of the current request. Common
+// types keep their JSON type, so numbers are logged as numbers, anything
+// else is marshaled like zerolog.Event.Interface. Keys matching
+// Config.Redact are redacted like headers, and strings are scrubbed with
+// Config.Scrubber.
+func Set(ctx echo.Context, key string, value interface{}) {
+	addFields(ctx, func(e *zerolog.Event, cfg *Config) {
+		if mode, ok := cfg.redactor.match(key); ok {
+			if v, keep := cfg.redactor.valueOf(mode, value); keep {
+				e.Str(key, v)
+			}
+			return
+		}
+		appendValue(e, key, value, cfg.Scrubber)
+	})
+}
+
+// addFields adds fn to the fields of the request, which is called with the
+// config of the middleware logging the request.
+func addFields(ctx echo.Context, fn func(e *zerolog.Event, cfg *Config)) {
+	f, ok := ctx.Get(fieldsKey).(*requestFields)
+	if !ok {
+		fieldsMu.Lock()
+		if f, ok = ctx.Get(fieldsKey).(*requestFields); !ok {
+			f = new(requestFields)
+			ctx.Set(fieldsKey, f)
+			atomic.StoreInt32(&fieldsAdded, 1)
+		}
+		fieldsMu.Unlock()
+	}
+
+	f.mu.Lock()
+	if !f.done {
+		f.funcs = append(f.funcs, fn)
+	}
+	f.mu.Unlock()
+}
+
+// requestFieldsOf returns the fields added to the request of c, if any.
+func requestFieldsOf(c Context) (*requestFields, bool) {
+	if atomic.LoadInt32(&fieldsAdded) == 0 {
+		return nil, false
+	}
+	f, ok := c.Get(fieldsKey).(*requestFields)
+	return f, ok
+}
+
+// apply adds the fields collected during the request to the event. Fields
+// added later are ignored.
+func (f *requestFields) apply(event *zerolog.Event, cfg *Config) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.done = true
+	for _, fn := range f.funcs {
+		fn(event, cfg)
+	}
+}
+
+// appendValue adds a value to the event with the most specific method.
+// Strings and error messages are scrubbed with s.
+func appendValue(e *zerolog.Event, key string, value interface{}, s *Scrubber) *zerolog.Event {
+	switch v := value.(type) {
+	case nil:
+		return e.Interface(key, nil)
+	case string:
+		return e.Str(key, s.Scrub(v))
+	case []byte:
+		return e.Bytes(key, v)
+	case bool:
+		return e.Bool(key, v)
+	case int:
+		return e.Int(key, v)
+	case int8:
+		return e.Int8(key, v)
+	case int16:
+		return e.Int16(key, v)
+	case int32:
+		return e.Int32(key, v)
+	case int64:
+		return e.Int64(key, v)
+	case uint:
+		return e.Uint(key, v)
+	case uint8:
+		return e.Uint8(key, v)
+	case uint16:
+		return e.Uint16(key, v)
+	case uint32:
+		return e.Uint32(key, v)
+	case uint64:
+		return e.Uint64(key, v)
+	case float32:
+		return e.Float32(key, v)
+	case float64:
+		return e.Float64(key, v)
+	case time.Time:
+		return e.Time(key, v)
+	case time.Duration:
+		return e.Dur(key, v)
+	case error:
+		if s != nil {
+			return e.Str(key, s.Scrub(v.Error()))
+		}
+		return e.AnErr(key, v)
+	case fmt.Stringer:
+		return e.Stringer(key, v)
+	default:
+		return e.Interface(key, v)
+	}
+}
@@ -0,0 +1,88 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_AddFields(t *testing.T) {
+	buf, e := testEcho(TagStatus)
+
+	e.GET("/info.html", func(c echo.Context) error {
+		AddFields(c, func(e *zerolog.Event) {
+			e.Str("user", "alice").Int("items", 3)
+		})
+		Set(c, "cached", true)
+		Set(c, "count", 55)
+		Set(c, "ratio", 0.5)
+		Set(c, "wait", 1500*time.Millisecond)
+		Set(c, "cause", errors.New("test"))
+		Set(c, "tags", []string{"a", "b"})
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, string(data), `"status":200,"user":"alice","items":3,"cached":true,"count":55,"ratio":0.5,"wait":1500,"cause":"test","tags":["a","b"]`)
+}
+
+func Test_AddFields_Concurrent(t *testing.T) {
+	buf, e := testEcho(TagStatus)
+
+	e.GET("/info.html", func(c echo.Context) error {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				Set(c, fmt.Sprintf("f%d", i), i)
+			}(i)
+		}
+		wg.Wait()
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	data, _ := io.ReadAll(buf)
+	for i := 0; i < 8; i++ {
+		require.Contains(t, string(data), fmt.Sprintf(`"f%d":%d`, i, i))
+	}
+}
+
+func Test_AddFields_AfterLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			Set(c, "user", c.Request().URL.Path)
+			return err
+		}
+	})
+	e.Use(New(Config{Format: []string{TagPath}, Output: buf}))
+	e.GET("/:user", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/alice", "/bob"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	require.NotContains(t, buf.String(), `"user"`)
+}
@@ -0,0 +1,438 @@
+package zerologger
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// logContext holds everything the field writers need to know about a
+// handled request.
+type logContext struct {
+	c         Context
+	err       error
+	latency   time.Duration
+	reqBody   *bodyReader
+	resBody   *bodyWriter
+	recovered *recovered
+
+	// echo and client are the Contexts of Echo requests and of outbound
+	// requests, kept here to save an allocation
+	echo   echoContext
+	client clientContext
+}
+
+var logContextPool = sync.Pool{
+	New: func() interface{} {
+		return new(logContext)
+	},
+}
+
+// fieldWriter adds one field to the log event of a request.
+type fieldWriter func(lc *logContext, event *zerolog.Event) *zerolog.Event
+
+// compiledField is a field writer and the path of its key. Fields sharing
+// the first elements of their path are nested in the same objects.
+type compiledField struct {
+	path  []string
+	write fieldWriter
+}
+
+// compileFormat parses a format once into a list of field writers, so the
+// middleware does not have to inspect the tags on every request. Unknown
+// tags are ignored.
+func compileFormat(cfg *Config, format []string, timestamp *atomic.Value) []fieldWriter {
+	fields := make([]compiledField, 0, len(format))
+	for _, entry := range format {
+		tag, spec := cfg.field(entry)
+		if w := compileTag(cfg, tag, spec, timestamp); w != nil {
+			fields = append(fields, compiledField{path: spec.path, write: w})
+		}
+	}
+	return groupFields(cfg, fields)
+}
+
+// groupFields nests compiled fields and wraps them in Config.FieldGroup.
+func groupFields(cfg *Config, fields []compiledField) []fieldWriter {
+	writers := nestFields(fields)
+	if cfg.FieldGroup == "" {
+		return writers
+	}
+
+	// Wrap every field, including custom tags, in a single object
+	return []fieldWriter{func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+		dict := zerolog.Dict()
+		for _, w := range writers {
+			dict = w(lc, dict)
+		}
+		return event.Dict(cfg.FieldGroup, dict)
+	}}
+}
+
+// nestFields groups fields by the first element of their path, in order of
+// first appearance, and wraps groups of nested fields in a single object.
+func nestFields(fields []compiledField) []fieldWriter {
+	type group struct {
+		name   string
+		fields []compiledField
+		write  fieldWriter
+	}
+
+	var groups []*group
+	byName := map[string]*group{}
+	for _, f := range fields {
+		if len(f.path) <= 1 {
+			groups = append(groups, &group{write: f.write})
+			continue
+		}
+		g, ok := byName[f.path[0]]
+		if !ok {
+			g = &group{name: f.path[0]}
+			byName[g.name] = g
+			groups = append(groups, g)
+		}
+		g.fields = append(g.fields, compiledField{path: f.path[1:], write: f.write})
+	}
+
+	writers := make([]fieldWriter, 0, len(groups))
+	for _, g := range groups {
+		if g.write != nil {
+			writers = append(writers, g.write)
+			continue
+		}
+		key, children := g.name, nestFields(g.fields)
+		writers = append(writers, func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			dict := zerolog.Dict()
+			for _, w := range children {
+				dict = w(lc, dict)
+			}
+			return event.Dict(key, dict)
+		})
+	}
+	return writers
+}
+
+// requestValue reads the value of a tag from the request, or returns false
+// if the field is omitted for the request.
+type requestValue func(c Context) (string, bool)
+
+// compileRequestValue returns the value of a tag that only depends on the
+// request, so it is shared by the access log and the per-request logger. It
+// returns false if the tag depends on the response, and a nil value if the
+// tag is never logged. Values in the trace_sampled format are "true" or
+// "false".
+func compileRequestValue(cfg *Config, tag string, spec fieldSpec) (requestValue, bool) {
+	// Tags reading a header are redacted by the name of the header
+	if header := tagHeader(cfg, tag); header != "" {
+		if mode, ok := cfg.redactor.match(header); ok {
+			return func(c Context) (string, bool) {
+				return cfg.redactor.value(mode, c.Header(header))
+			}, true
+		}
+	}
+
+	// Addresses are anonymized unless they were redacted
+	if cfg.ipAnonymizer.carries(cfg, tag) {
+		if cfg.AnonymizeIP == IPDrop {
+			return nil, true
+		}
+		value := ipValue(cfg, tag)
+		return func(c Context) (string, bool) {
+			return cfg.ipAnonymizer.anonymize(value(c)), true
+		}, true
+	}
+
+	var value func(c Context) string
+	switch tag {
+	case TagReferer:
+		value = func(c Context) string {
+			return cfg.Scrubber.Scrub(cfg.redactor.url(c.Header("Referer")))
+		}
+	case TagProtocol:
+		value = Context.Proto
+		if spec.format == formatVersion {
+			value = func(c Context) string {
+				return strings.TrimPrefix(c.Proto(), "HTTP/")
+			}
+		}
+	case TagID:
+		value = func(c Context) string {
+			return c.Header(cfg.RequestIDHeader)
+		}
+	case TagIP:
+		value = Context.RealIP
+	case TagIPs:
+		value = func(c Context) string {
+			return c.Header(echo.HeaderXForwardedFor)
+		}
+	case TagHost:
+		value = Context.Host
+	case TagMethod:
+		value = Context.Method
+	case TagPath:
+		value = func(c Context) string {
+			return cfg.Scrubber.Scrub(c.Path())
+		}
+	case TagURL:
+		url := Context.URL
+		if spec.format == formatAbsoluteURL {
+			url = absoluteURL
+		}
+		value = func(c Context) string {
+			return cfg.Scrubber.Scrub(cfg.redactor.url(url(c)))
+		}
+	case TagUA:
+		value = func(c Context) string {
+			return cfg.Scrubber.Scrub(c.Header("User-Agent"))
+		}
+	case TagRoute:
+		value = Context.Route
+	case TagTraceID, TagSpanID, TagParentSpanID, TagTraceFlags, TagTraceState:
+		switch spec.format {
+		case formatGCPTrace:
+			prefix := "projects/" + cfg.ProjectID + "/traces/"
+			return func(c Context) (string, bool) {
+				if tc := traceOf(c); tc.Valid() {
+					return prefix + tc.TraceID, true
+				}
+				return "", false
+			}, true
+		case formatSampled:
+			return func(c Context) (string, bool) {
+				if tc := traceOf(c); tc.Valid() {
+					return strconv.FormatBool(tc.Sampled()), true
+				}
+				return "", false
+			}, true
+		}
+		trace := traceValue(tag)
+		return func(c Context) (string, bool) {
+			v := trace(traceOf(c))
+			return v, v != ""
+		}, true
+	default:
+		if !strings.HasPrefix(tag, TagHeader) || len(tag) == len(TagHeader) {
+			return nil, false
+		}
+		header := http.CanonicalHeaderKey(tag[len(TagHeader):])
+		value = func(c Context) string {
+			return cfg.Scrubber.Scrub(c.Header(header))
+		}
+	}
+
+	return func(c Context) (string, bool) {
+		return value(c), true
+	}, true
+}
+
+// compileTag returns the field writer for a single built-in or registered
+// tag, or nil if the tag is unknown. Tags write their value under the last
+// element of the path of spec.
+func compileTag(cfg *Config, tag string, spec fieldSpec, timestamp *atomic.Value) fieldWriter {
+	key := spec.key()
+
+	if value, ok := compileRequestValue(cfg, tag, spec); ok {
+		if value == nil {
+			return nil
+		}
+		if spec.format == formatSampled {
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				if v, ok := value(lc.c); ok {
+					return event.Bool(key, v == "true")
+				}
+				return event
+			}
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if v, ok := value(lc.c); ok {
+				return event.Str(key, v)
+			}
+			return event
+		}
+	}
+
+	switch tag {
+	case TagTime:
+		return func(_ *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, timestamp.Load().(string))
+		}
+	case TagPid:
+		if spec.format == formatNumber {
+			pid := os.Getpid()
+			return func(_ *logContext, event *zerolog.Event) *zerolog.Event {
+				return event.Int(key, pid)
+			}
+		}
+		pid := strconv.Itoa(os.Getpid())
+		return func(_ *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, pid)
+		}
+	case TagLatency:
+		switch {
+		case spec.format == formatDurationString:
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				return event.Str(key, strconv.FormatFloat(lc.latency.Seconds(), 'f', -1, 64)+"s")
+			}
+		case spec.format == formatSeconds:
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				return event.Float64(key, lc.latency.Seconds())
+			}
+		case spec.format == formatNanoseconds:
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				return event.Int64(key, lc.latency.Nanoseconds())
+			}
+		case cfg.PrettyLatency:
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				return event.Str(key, lc.latency.String())
+			}
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Dur(key, lc.latency)
+		}
+	case TagBody:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.reqBody == nil {
+				return event
+			}
+			contentType := lc.c.Header(echo.HeaderContentType)
+			return lc.reqBody.capture.log(event, key, cfg, contentType)
+		}
+	case TagBytesReceived:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			cl := lc.c.Header(echo.HeaderContentLength)
+			if cl == "" {
+				return event.Int(key, 0)
+			}
+			i, _ := strconv.ParseInt(cl, 10, 64)
+			return event.Int64(key, i)
+		}
+	case TagBytesSent:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Int64(key, lc.c.Size())
+		}
+	case TagStatus:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Int(key, lc.c.Status())
+		}
+	case TagResBody:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.resBody == nil || !lc.resBody.captured() {
+				return event
+			}
+			contentType := lc.c.ResponseHeader(echo.HeaderContentType)
+			return lc.resBody.capture.log(event, key, cfg, contentType)
+		}
+	case TagQueryStringParams:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, cfg.Scrubber.Scrub(cfg.redactor.query(lc.c.QueryString())))
+		}
+	case TagGoroutines:
+		return func(_ *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Int(key, runtime.NumGoroutine())
+		}
+	case TagError:
+		if cfg.redactor != nil || cfg.Scrubber != nil {
+			// Messages may carry a URL, e.g. the ones of a Transport, or
+			// the input that failed
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				if lc.err == nil {
+					return event
+				}
+				return event.Str(key, cfg.Scrubber.Scrub(cfg.redactor.text(lc.err.Error())))
+			}
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.err == nil {
+				return event
+			}
+			if key == zerolog.ErrorFieldName {
+				return event.Err(lc.err)
+			}
+			return event.AnErr(key, lc.err)
+		}
+	}
+
+	// Check if we have a value tag i.e.: "query:x-key"
+	switch {
+	case strings.HasPrefix(tag, TagQuery):
+		name := tag[len(TagQuery):]
+		if mode, ok := cfg.redactor.match(name); ok {
+			return redactedStr(cfg.redactor, key, mode, func(lc *logContext) string {
+				return lc.c.QueryParam(name)
+			})
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, cfg.Scrubber.Scrub(lc.c.QueryParam(name)))
+		}
+	case strings.HasPrefix(tag, TagForm):
+		name := tag[len(TagForm):]
+		if mode, ok := cfg.redactor.match(name); ok {
+			return redactedStr(cfg.redactor, key, mode, func(lc *logContext) string {
+				return lc.c.FormValue(name)
+			})
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, cfg.Scrubber.Scrub(lc.c.FormValue(name)))
+		}
+	case strings.HasPrefix(tag, TagCookie):
+		name := tag[len(TagCookie):]
+		if mode, ok := cfg.redactor.match(name); ok {
+			return redactedStr(cfg.redactor, key, mode, func(lc *logContext) string {
+				return lc.c.Cookie(name)
+			})
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			v := lc.c.Cookie(name)
+			if v == "" {
+				return event
+			}
+			return event.Str(key, cfg.Scrubber.Scrub(v))
+		}
+	case strings.HasPrefix(tag, TagLocals):
+		name := tag[len(TagLocals):]
+		if mode, ok := cfg.redactor.match(name); ok {
+			return redactedStr(cfg.redactor, key, mode, func(lc *logContext) string {
+				switch v := lc.c.Get(name).(type) {
+				case []byte:
+					return string(v)
+				case nil:
+					return ""
+				default:
+					return fmt.Sprintf("%v", v)
+				}
+			})
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			switch v := lc.c.Get(name).(type) {
+			case []byte:
+				if cfg.Scrubber != nil {
+					return event.Str(key, cfg.Scrubber.Scrub(string(v)))
+				}
+				return event.Bytes(key, v)
+			case string:
+				return event.Str(key, cfg.Scrubber.Scrub(v))
+			case nil:
+				return event
+			default:
+				return event.Str(key, cfg.Scrubber.Scrub(fmt.Sprintf("%v", v)))
+			}
+		}
+	}
+
+	return compileCustomTag(tag, key)
+}
+
+// absoluteURL returns the URL of the request with its scheme and host, which
+// servers do not receive in the request line.
+func absoluteURL(c Context) string {
+	return c.Scheme() + "://" + c.Host() + c.RequestURI()
+}
@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	github.com/labstack/echo/v4 v4.5.0
+	github.com/labstack/gommon v0.3.0
 	github.com/rs/zerolog v1.23.0
 	github.com/stretchr/testify v1.7.0
 )
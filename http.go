@@ -0,0 +1,236 @@
+package zerologger
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// NewHTTP creates a new zerolog middleware for net/http, and for routers
+// built on it such as chi. It supports the same tags and options as New.
+// TagRoute is given by Config.RouteResolver.
+func NewHTTP(config ...Config) func(http.Handler) http.Handler {
+	return newMiddleware(setConfig(config...)).HTTPHandler
+}
+
+// HTTPHandler is the net/http middleware, like Handler is for Echo.
+//
+// The handler is given a writer that records the status and size of the
+// response, and implements the same optional interfaces, such as
+// http.Flusher and http.Hijacker, as the writer of the server. Recovered
+// panics are answered with the plain text status, like http.Error.
+func (m *Middleware) HTTPHandler(next http.Handler) http.Handler {
+	resolve := m.cfg.RouteResolver
+	pool := sync.Pool{
+		New: func() interface{} {
+			return new(httpContext)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := pool.Get().(*httpContext)
+		c.res.ResponseWriter = w
+		c.req, c.w, c.next, c.resolve = r, wrapWriter(w, &c.res), next, resolve
+		if resolve != nil {
+			c.route = resolve(r)
+		}
+
+		m.Serve(c)
+
+		*c = httpContext{}
+		pool.Put(c)
+	})
+}
+
+// httpContext is the Adapter of net/http.
+type httpContext struct {
+	req     *http.Request
+	w       http.ResponseWriter
+	res     responseWriter
+	route   string
+	store   map[string]interface{}
+	next    http.Handler
+	resolve func(r *http.Request) string
+}
+
+var _ Adapter = (*httpContext)(nil)
+
+func (c *httpContext) Method() string {
+	return c.req.Method
+}
+
+func (c *httpContext) Scheme() string {
+	return requestScheme(c.req)
+}
+
+func (c *httpContext) Host() string {
+	return c.req.Host
+}
+
+func (c *httpContext) Path() string {
+	return c.req.URL.Path
+}
+
+func (c *httpContext) URL() string {
+	return c.req.URL.String()
+}
+
+func (c *httpContext) RequestURI() string {
+	return c.req.URL.RequestURI()
+}
+
+func (c *httpContext) Proto() string {
+	return c.req.Proto
+}
+
+func (c *httpContext) Header(name string) string {
+	return headerValue(c.req.Header, name)
+}
+
+// RealIP returns the first address of X-Forwarded-For or X-Real-IP, or the
+// remote address, like echo.Context.RealIP.
+func (c *httpContext) RealIP() string {
+	if ip := c.req.Header.Get("X-Forwarded-For"); ip != "" {
+		i := strings.IndexByte(ip, ',')
+		if i < 0 {
+			i = len(ip)
+		}
+		return strings.TrimSpace(ip[:i])
+	}
+	if ip := c.req.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	ip, _, _ := net.SplitHostPort(c.req.RemoteAddr)
+	return ip
+}
+
+func (c *httpContext) Route() string {
+	return c.route
+}
+
+// Param returns an empty string, since net/http does not know the route.
+func (c *httpContext) Param(string) string {
+	return ""
+}
+
+func (c *httpContext) QueryString() string {
+	return c.req.URL.RawQuery
+}
+
+func (c *httpContext) QueryParam(name string) string {
+	return queryValue(c.req.URL.RawQuery, name)
+}
+
+func (c *httpContext) FormValue(name string) string {
+	return c.req.FormValue(name)
+}
+
+func (c *httpContext) Cookie(name string) string {
+	if cookie, err := c.req.Cookie(name); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (c *httpContext) Get(key string) interface{} {
+	return c.store[key]
+}
+
+func (c *httpContext) Context() context.Context {
+	return c.req.Context()
+}
+
+// Status returns the status of the response, which is 200 if the handler
+// wrote nothing.
+func (c *httpContext) Status() int {
+	if c.res.status == 0 {
+		return http.StatusOK
+	}
+	return c.res.status
+}
+
+func (c *httpContext) Size() int64 {
+	return c.res.size
+}
+
+func (c *httpContext) ResponseHeader(name string) string {
+	return c.res.Header().Get(name)
+}
+
+func (c *httpContext) Set(key string, value interface{}) {
+	if c.store == nil {
+		c.store = map[string]interface{}{}
+	}
+	c.store[key] = value
+}
+
+func (c *httpContext) SetRequestHeader(name, value string) {
+	c.req.Header.Set(name, value)
+}
+
+func (c *httpContext) SetResponseHeader(name, value string) {
+	c.res.Header().Set(name, value)
+}
+
+func (c *httpContext) SetContext(ctx context.Context) {
+	c.req = c.req.WithContext(ctx)
+}
+
+func (c *httpContext) Body() io.ReadCloser {
+	return c.req.Body
+}
+
+func (c *httpContext) SetBody(body io.ReadCloser) {
+	c.req.Body = body
+}
+
+func (c *httpContext) Writer() http.ResponseWriter {
+	return c.w
+}
+
+func (c *httpContext) SetWriter(w http.ResponseWriter) {
+	c.w = w
+}
+
+func (c *httpContext) Next() error {
+	c.next.ServeHTTP(c.w, c.req)
+
+	// Routers like chi only know the route once they routed the request
+	if c.resolve != nil {
+		c.route = c.resolve(c.req)
+	}
+	return nil
+}
+
+// Error responds with the plain text status 500, unless the response was
+// started already.
+func (c *httpContext) Error(error) {
+	if c.res.status != 0 {
+		return
+	}
+	http.Error(c.w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// requestScheme returns the scheme of a request, from the connection or
+// from the headers set by proxies, like echo.Context.Scheme.
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if scheme := r.Header.Get("X-Forwarded-Proto"); scheme != "" {
+		return scheme
+	}
+	if scheme := r.Header.Get("X-Forwarded-Protocol"); scheme != "" {
+		return scheme
+	}
+	if ssl := r.Header.Get("X-Forwarded-Ssl"); ssl == "on" {
+		return "https"
+	}
+	if scheme := r.Header.Get("X-Url-Scheme"); scheme != "" {
+		return scheme
+	}
+	return "http"
+}
@@ -0,0 +1,137 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_NewHTTP(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mw := NewHTTP(Config{
+		Format:              []string{TagMethod, TagRoute, TagStatus, TagBytesSent, TagIP, TagID, "header:X-Tenant"},
+		ContextFormat:       []string{TagRoute},
+		EnableContextLogger: true,
+		RequestIDGenerator:  func() string { return "generated" },
+		RouteResolver: func(r *http.Request) string {
+			if strings.HasPrefix(r.URL.Path, "/users/") {
+				return "/users/{id}"
+			}
+			return ""
+		},
+		Output: buf,
+	})
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("handler")
+		require.Equal(t, "generated", RequestIDFromContext(r.Context()))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set("X-Tenant", "acme")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	require.Equal(t, http.StatusCreated, res.Code)
+	require.Equal(t, "created", res.Body.String())
+	require.Equal(t, "generated", res.Header().Get("X-Request-ID"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"route":"/users/{id}"`)
+	require.Contains(t, lines[0], `"message":"handler"`)
+	require.Contains(t, lines[1], `"method":"POST",`+
+		`"route":"/users/{id}",`+
+		`"status":201,`+
+		`"bytesSent":7,`+
+		`"ip":"192.0.2.1",`+
+		`"id":"generated",`+
+		`"X-Tenant":"acme"`)
+}
+
+func Test_NewHTTP_ImplicitStatus(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := NewHTTP(Config{Format: []string{TagStatus, TagBytesSent}, Output: buf})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, buf.String(), `"status":200,"bytesSent":0`)
+}
+
+func Test_NewHTTP_Recover(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := NewHTTP(Config{Format: []string{TagStatus}, Recover: true, Output: buf})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}),
+	)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "Internal Server Error\n", res.Body.String())
+	require.Contains(t, buf.String(), `"status":500,"panic":"boom"`)
+}
+
+// pushWriter is a response writer that supports http.Pusher and
+// io.ReaderFrom, but not http.Hijacker.
+type pushWriter struct {
+	*httptest.ResponseRecorder
+	pushed string
+}
+
+func (w *pushWriter) Push(target string, _ *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+func (w *pushWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func Test_NewHTTP_Interfaces(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := NewHTTP(Config{Format: []string{TagStatus, TagBytesSent, TagResBody}, Output: buf})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hijacker := w.(http.Hijacker)
+			require.False(t, hijacker)
+			_, flusher := w.(http.Flusher)
+			require.True(t, flusher)
+
+			require.NoError(t, w.(http.Pusher).Push("/style.css", nil))
+			w.Header().Set("Content-Type", "text/plain")
+			w.(io.ReaderFrom).ReadFrom(strings.NewReader("pushed"))
+		}),
+	)
+
+	res := &pushWriter{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "/style.css", res.pushed)
+	require.Equal(t, "pushed", res.Body.String())
+	require.Contains(t, buf.String(), `"status":200,"bytesSent":6,"resBody":"pushed"`)
+
+	// httptest.ResponseRecorder is neither a Hijacker nor a Pusher
+	buf.Reset()
+	handler = NewHTTP(Config{Format: []string{TagStatus}, Output: buf})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hijacker := w.(http.Hijacker)
+			_, pusher := w.(http.Pusher)
+			require.False(t, hijacker || pusher)
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, buf.String(), `"status":204`)
+}
@@ -0,0 +1,68 @@
+package zerologger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelFunc returns the level used to log a request, given the response
+// status and the error returned by the handler chain.
+type LevelFunc func(c Context, status int, err error) zerolog.Level
+
+// DefaultLevel logs 200 at Info, 4xx at Warn, 5xx at Error and any other
+// status at Debug.
+func DefaultLevel(_ Context, status int, _ error) zerolog.Level {
+	switch {
+	case status == http.StatusOK:
+		return zerolog.InfoLevel
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return zerolog.WarnLevel
+	case status >= http.StatusInternalServerError:
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.DebugLevel
+	}
+}
+
+// SuccessLevel logs every 2xx and 3xx at Info, 4xx at Warn, 5xx at Error
+// and any other status at Debug.
+func SuccessLevel(_ Context, status int, _ error) zerolog.Level {
+	switch {
+	case status >= http.StatusOK && status < http.StatusBadRequest:
+		return zerolog.InfoLevel
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return zerolog.WarnLevel
+	case status >= http.StatusInternalServerError:
+		return zerolog.ErrorLevel
+	default:
+		return zerolog.DebugLevel
+	}
+}
+
+// NotFoundAsInfo logs 404 responses at Info and defers to next otherwise.
+func NotFoundAsInfo(next LevelFunc) LevelFunc {
+	return func(c Context, status int, err error) zerolog.Level {
+		if status == http.StatusNotFound {
+			return zerolog.InfoLevel
+		}
+		return next(c, status, err)
+	}
+}
+
+// CanceledAsInfo logs requests canceled by the client at Info and defers to
+// next otherwise. A request counts as canceled when the handler returned
+// context.Canceled or the request context was canceled.
+func CanceledAsInfo(next LevelFunc) LevelFunc {
+	return func(c Context, status int, err error) zerolog.Level {
+		if errors.Is(err, context.Canceled) {
+			return zerolog.InfoLevel
+		}
+		if c != nil && errors.Is(c.Context().Err(), context.Canceled) {
+			return zerolog.InfoLevel
+		}
+		return next(c, status, err)
+	}
+}
@@ -0,0 +1,87 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_LevelFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     LevelFunc
+		status int
+		err    error
+		level  zerolog.Level
+	}{
+		{"Default200", DefaultLevel, http.StatusOK, nil, zerolog.InfoLevel},
+		{"Default201", DefaultLevel, http.StatusCreated, nil, zerolog.DebugLevel},
+		{"Default404", DefaultLevel, http.StatusNotFound, nil, zerolog.WarnLevel},
+		{"Default500", DefaultLevel, http.StatusInternalServerError, nil, zerolog.ErrorLevel},
+		{"Success201", SuccessLevel, http.StatusCreated, nil, zerolog.InfoLevel},
+		{"Success302", SuccessLevel, http.StatusFound, nil, zerolog.InfoLevel},
+		{"Success101", SuccessLevel, http.StatusSwitchingProtocols, nil, zerolog.DebugLevel},
+		{"Success400", SuccessLevel, http.StatusBadRequest, nil, zerolog.WarnLevel},
+		{"Success503", SuccessLevel, http.StatusServiceUnavailable, nil, zerolog.ErrorLevel},
+		{"NotFound404", NotFoundAsInfo(SuccessLevel), http.StatusNotFound, nil, zerolog.InfoLevel},
+		{"NotFound400", NotFoundAsInfo(SuccessLevel), http.StatusBadRequest, nil, zerolog.WarnLevel},
+		{"CanceledErr", CanceledAsInfo(SuccessLevel), http.StatusInternalServerError, context.Canceled, zerolog.InfoLevel},
+		{"CanceledNot", CanceledAsInfo(SuccessLevel), http.StatusInternalServerError, errors.New("test"), zerolog.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.level, tt.fn(nil, tt.status, tt.err))
+		})
+	}
+}
+
+func Test_LevelFunc_Canceled(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	buf := new(bytes.Buffer)
+	handler := NewHTTP(Config{
+		Format:    []string{TagStatus},
+		Output:    buf,
+		LevelFunc: CanceledAsInfo(SuccessLevel),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(canceled)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), fmt.Sprintf(`"%s":"%s","status":503`, zerolog.LevelFieldName, zerolog.LevelInfoValue))
+}
+
+func Test_LevelFunc_Config(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:    []string{TagStatus},
+		Output:    buf,
+		LevelFunc: SuccessLevel,
+	}))
+
+	e.POST("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, http.StatusCreated, res.Code)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, zerolog.LevelFieldName, zerolog.LevelInfoValue))
+}
@@ -0,0 +1,281 @@
+package zerologger
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/rs/zerolog"
+)
+
+// PrefixFieldName is the field name of the prefix set with Logger.SetPrefix.
+var PrefixFieldName = "prefix"
+
+// Logger implements echo.Logger with zerolog, so the logs of Echo itself,
+// such as startup messages and the c.Logger() calls of handlers, are
+// structured like the access log.
+type Logger struct {
+	mu     sync.RWMutex
+	logger zerolog.Logger
+	prefix string
+	level  log.Lvl
+}
+
+var _ echo.Logger = (*Logger)(nil)
+
+// NewLogger returns an echo.Logger writing to logger.
+func NewLogger(logger zerolog.Logger) *Logger {
+	return &Logger{logger: logger, level: echoLevel(logger.GetLevel())}
+}
+
+// Install sets a Logger backed by the logger of the config as the logger of
+// e, and adds the middleware of the config to e. With the per-request
+// logger enabled, c.Logger() in handlers carries the fields of
+// Config.ContextFormat. Call Close on the returned middleware once the
+// server has shut down to stop the timestamp goroutine.
+func Install(e *echo.Echo, config ...Config) *Middleware {
+	cfg := setConfig(config...)
+	m := newMiddleware(cfg)
+	e.Logger = NewLogger(cfg.logger)
+	e.Use(m.Handler())
+	return m
+}
+
+// with returns a copy of the Logger with the fields added by fields.
+func (l *Logger) with(fields func(zerolog.Context) zerolog.Context) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return &Logger{logger: fields(l.logger.With()).Logger(), prefix: l.prefix, level: l.level}
+}
+
+// Output returns a writer that logs each write as a message at Info level.
+// Echo prints its startup messages to it.
+func (l *Logger) Output() io.Writer {
+	return logWriter{l}
+}
+
+// SetOutput sets the destination of the underlying zerolog logger.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.logger = l.logger.Output(w)
+}
+
+// Prefix returns the prefix of the logs.
+func (l *Logger) Prefix() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.prefix
+}
+
+// SetPrefix sets a prefix that is logged with every message under
+// PrefixFieldName.
+func (l *Logger) SetPrefix(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prefix = p
+}
+
+// Level returns the minimum level of the logs.
+func (l *Logger) Level() log.Lvl {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.level
+}
+
+// SetLevel sets the minimum level of the logs.
+func (l *Logger) SetLevel(v log.Lvl) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level = v
+	l.logger = l.logger.Level(zerologLevel(v))
+}
+
+// SetHeader is ignored, the format of the logs is defined by zerolog.
+func (l *Logger) SetHeader(string) {}
+
+// Print logs the message without a level.
+func (l *Logger) Print(i ...interface{}) {
+	l.event(zerolog.NoLevel).Msg(fmt.Sprint(i...))
+}
+
+// Printf logs the formatted message without a level.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	l.event(zerolog.NoLevel).Msgf(format, args...)
+}
+
+// Printj logs the fields of j without a level.
+func (l *Logger) Printj(j log.JSON) {
+	l.event(zerolog.NoLevel).Fields(j).Send()
+}
+
+// Debug logs the message at Debug level.
+func (l *Logger) Debug(i ...interface{}) {
+	l.event(zerolog.DebugLevel).Msg(fmt.Sprint(i...))
+}
+
+// Debugf logs the formatted message at Debug level.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.event(zerolog.DebugLevel).Msgf(format, args...)
+}
+
+// Debugj logs the fields of j at Debug level.
+func (l *Logger) Debugj(j log.JSON) {
+	l.event(zerolog.DebugLevel).Fields(j).Send()
+}
+
+// Info logs the message at Info level.
+func (l *Logger) Info(i ...interface{}) {
+	l.event(zerolog.InfoLevel).Msg(fmt.Sprint(i...))
+}
+
+// Infof logs the formatted message at Info level.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.event(zerolog.InfoLevel).Msgf(format, args...)
+}
+
+// Infoj logs the fields of j at Info level.
+func (l *Logger) Infoj(j log.JSON) {
+	l.event(zerolog.InfoLevel).Fields(j).Send()
+}
+
+// Warn logs the message at Warn level.
+func (l *Logger) Warn(i ...interface{}) {
+	l.event(zerolog.WarnLevel).Msg(fmt.Sprint(i...))
+}
+
+// Warnf logs the formatted message at Warn level.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.event(zerolog.WarnLevel).Msgf(format, args...)
+}
+
+// Warnj logs the fields of j at Warn level.
+func (l *Logger) Warnj(j log.JSON) {
+	l.event(zerolog.WarnLevel).Fields(j).Send()
+}
+
+// Error logs the message at Error level.
+func (l *Logger) Error(i ...interface{}) {
+	l.event(zerolog.ErrorLevel).Msg(fmt.Sprint(i...))
+}
+
+// Errorf logs the formatted message at Error level.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.event(zerolog.ErrorLevel).Msgf(format, args...)
+}
+
+// Errorj logs the fields of j at Error level.
+func (l *Logger) Errorj(j log.JSON) {
+	l.event(zerolog.ErrorLevel).Fields(j).Send()
+}
+
+// Fatal logs the message and calls os.Exit(1), like zerolog.Logger.Fatal.
+func (l *Logger) Fatal(i ...interface{}) {
+	l.event(zerolog.FatalLevel).Msg(fmt.Sprint(i...))
+}
+
+// Fatalf logs the formatted message and calls os.Exit(1).
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.event(zerolog.FatalLevel).Msgf(format, args...)
+}
+
+// Fatalj logs the fields of j and calls os.Exit(1).
+func (l *Logger) Fatalj(j log.JSON) {
+	l.event(zerolog.FatalLevel).Fields(j).Send()
+}
+
+// Panic logs the message and panics, like zerolog.Logger.Panic.
+func (l *Logger) Panic(i ...interface{}) {
+	l.event(zerolog.PanicLevel).Msg(fmt.Sprint(i...))
+}
+
+// Panicf logs the formatted message and panics.
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.event(zerolog.PanicLevel).Msgf(format, args...)
+}
+
+// Panicj logs the fields of j and panics.
+func (l *Logger) Panicj(j log.JSON) {
+	l.event(zerolog.PanicLevel).Fields(j).Send()
+}
+
+// event starts a log event. Fatal and Panic events terminate like their
+// zerolog counterparts once sent.
+func (l *Logger) event(level zerolog.Level) *zerolog.Event {
+	l.mu.RLock()
+	logger, prefix := l.logger, l.prefix
+	l.mu.RUnlock()
+
+	var e *zerolog.Event
+	switch level {
+	case zerolog.FatalLevel:
+		e = logger.Fatal()
+	case zerolog.PanicLevel:
+		e = logger.Panic()
+	case zerolog.NoLevel:
+		e = logger.Log()
+	default:
+		e = logger.WithLevel(level)
+	}
+	if prefix != "" {
+		e = e.Str(PrefixFieldName, prefix)
+	}
+	return e
+}
+
+// ansiColor matches the color codes Echo adds to its startup messages.
+var ansiColor = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// logWriter logs what is written to it as messages.
+type logWriter struct {
+	l *Logger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	if msg := strings.TrimSpace(ansiColor.ReplaceAllString(string(p), "")); msg != "" {
+		w.l.event(zerolog.InfoLevel).Msg(msg)
+	}
+	return len(p), nil
+}
+
+// zerologLevel returns the zerolog level of an Echo level.
+func zerologLevel(v log.Lvl) zerolog.Level {
+	switch v {
+	case log.DEBUG:
+		return zerolog.DebugLevel
+	case log.INFO:
+		return zerolog.InfoLevel
+	case log.WARN:
+		return zerolog.WarnLevel
+	case log.ERROR:
+		return zerolog.ErrorLevel
+	case log.OFF:
+		return zerolog.Disabled
+	}
+	return zerolog.TraceLevel
+}
+
+// echoLevel returns the Echo level of a zerolog level.
+func echoLevel(level zerolog.Level) log.Lvl {
+	switch {
+	case level <= zerolog.DebugLevel:
+		return log.DEBUG
+	case level == zerolog.InfoLevel:
+		return log.INFO
+	case level == zerolog.WarnLevel:
+		return log.WARN
+	case level == zerolog.Disabled:
+		return log.OFF
+	}
+	return log.ERROR
+}
@@ -0,0 +1,113 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_Logger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(zerolog.New(buf))
+	require.Equal(t, log.DEBUG, l.Level())
+
+	l.Print("print ", 1)
+	l.Infof("info %d", 2)
+	l.Warnj(log.JSON{"key": "value"})
+	l.SetPrefix("echo")
+	l.Error("error")
+	require.Equal(t, "echo", l.Prefix())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, []string{
+		`{"message":"print 1"}`,
+		`{"level":"info","message":"info 2"}`,
+		`{"level":"warn","key":"value"}`,
+		`{"level":"error","prefix":"echo","message":"error"}`,
+	}, lines)
+
+	buf.Reset()
+	l.SetLevel(log.WARN)
+	l.Info("hidden")
+	l.Warn("shown")
+	require.Equal(t, log.WARN, l.Level())
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), "shown")
+
+	out := new(bytes.Buffer)
+	l.SetOutput(out)
+	l.Warn("moved")
+	require.NotContains(t, buf.String(), "moved")
+	require.Contains(t, out.String(), "moved")
+
+	require.PanicsWithValue(t, "panic", func() { l.Panic("panic") })
+}
+
+func Test_Logger_Output(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(zerolog.New(buf))
+
+	_, err := l.Output().Write([]byte("\x1b[32mhttp server started on [::]:8080\x1b[0m\n"))
+	require.NoError(t, err)
+	require.Equal(t, `{"level":"info","message":"http server started on [::]:8080"}`+"\n", buf.String())
+}
+
+func Test_Install(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	Install(e, Config{
+		Format:              []string{TagStatus},
+		ContextFormat:       []string{TagRoute},
+		EnableContextLogger: true,
+		Output:              buf,
+	})
+	e.Logger.SetPrefix("echo")
+
+	e.GET("/info.html", func(c echo.Context) error {
+		c.Logger().Info("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, echoURI, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	require.Contains(t, buf.String(), `{"level":"info","route":"/info.html","prefix":"echo",`)
+	require.Contains(t, buf.String(), `"message":"handler"`)
+	require.Contains(t, buf.String(), `"status":200`)
+}
+
+func Test_Install_SetOutput(t *testing.T) {
+	buf, out := new(bytes.Buffer), new(bytes.Buffer)
+	e := echo.New()
+	m := Install(e, Config{
+		Format:              []string{TagStatus, TagTime},
+		ContextFormat:       []string{TagRoute},
+		EnableContextLogger: true,
+		Output:              buf,
+	})
+	e.Logger.SetOutput(out)
+	e.Logger.SetLevel(log.WARN)
+
+	e.GET("/info.html", func(c echo.Context) error {
+		c.Logger().Info("skipped")
+		c.Logger().Warn("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, echoURI, nil))
+
+	require.Regexp(t, `^\{"level":"warn","route":"/info.html",.*"message":"handler"\}\n$`, out.String())
+	require.NotContains(t, buf.String(), "handler")
+	require.Contains(t, buf.String(), `"status":200`)
+	require.NoError(t, m.Close())
+}
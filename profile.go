@@ -0,0 +1,198 @@
+package zerologger
+
+import "strings"
+
+// Profile selects the names and layout of the fields written for the
+// built-in tags, so logs match what the log backend expects.
+type Profile int
+
+const (
+	// ProfileFlat names every field after its tag, e.g. "status".
+	ProfileFlat Profile = iota
+
+	// ProfileGCP writes the httpRequest object and the trace fields of
+	// Google Cloud Logging. Trace fields need Config.ProjectID.
+	// See https://cloud.google.com/logging/docs/structured-logging
+	ProfileGCP
+
+	// ProfileECS writes the fields of the Elastic Common Schema as nested
+	// objects, e.g. {"http":{"request":{"method":"GET"}}}.
+	// See https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html
+	ProfileECS
+
+	// ProfileOTel writes the attributes of the OpenTelemetry semantic
+	// conventions as flat dotted keys, e.g. "http.request.method".
+	// See https://opentelemetry.io/docs/specs/semconv/http/http-spans/
+	ProfileOTel
+)
+
+// valueFormat selects how a tag writes its value when a profile expects a
+// different type or unit than the default.
+type valueFormat int
+
+const (
+	formatDefault        valueFormat = iota
+	formatDurationString             // "0.123s"
+	formatSeconds                    // 0.123
+	formatNanoseconds                // 123000000
+	formatNumber                     // 123 instead of "123"
+	formatVersion                    // "1.1" instead of "HTTP/1.1"
+	formatGCPTrace                   // "projects/<project>/traces/<traceId>"
+	formatSampled                    // true instead of "01"
+	formatAbsoluteURL                // "https://example.com/a?b" instead of "/a?b"
+)
+
+// fieldSpec is the path of the key of a field and the format of its value.
+type fieldSpec struct {
+	path   []string
+	format valueFormat
+}
+
+// key returns the key the value is written to.
+func (s fieldSpec) key() string {
+	return s.path[len(s.path)-1]
+}
+
+// gcpFields are the fields of ProfileGCP.
+var gcpFields = map[string]fieldSpec{
+	TagMethod:        {path: []string{"httpRequest", "requestMethod"}},
+	TagURL:           {path: []string{"httpRequest", "requestUrl"}, format: formatAbsoluteURL},
+	TagStatus:        {path: []string{"httpRequest", "status"}},
+	TagBytesSent:     {path: []string{"httpRequest", "responseSize"}},
+	TagBytesReceived: {path: []string{"httpRequest", "requestSize"}},
+	TagUA:            {path: []string{"httpRequest", "userAgent"}},
+	TagIP:            {path: []string{"httpRequest", "remoteIp"}},
+	TagReferer:       {path: []string{"httpRequest", "referer"}},
+	TagProtocol:      {path: []string{"httpRequest", "protocol"}},
+	TagLatency:       {path: []string{"httpRequest", "latency"}, format: formatDurationString},
+	TagTraceID:       {path: []string{"logging.googleapis.com/trace"}, format: formatGCPTrace},
+	TagSpanID:        {path: []string{"logging.googleapis.com/spanId"}},
+	TagTraceFlags:    {path: []string{"logging.googleapis.com/trace_sampled"}, format: formatSampled},
+}
+
+// ecsFields are the fields of ProfileECS.
+var ecsFields = map[string]fieldSpec{
+	TagTime:              {path: []string{"@timestamp"}},
+	TagReferer:           {path: []string{"http", "request", "referrer"}},
+	TagProtocol:          {path: []string{"http", "version"}, format: formatVersion},
+	TagPid:               {path: []string{"process", "pid"}, format: formatNumber},
+	TagID:                {path: []string{"http", "request", "id"}},
+	TagIP:                {path: []string{"client", "ip"}},
+	TagHost:              {path: []string{"url", "domain"}},
+	TagMethod:            {path: []string{"http", "request", "method"}},
+	TagPath:              {path: []string{"url", "path"}},
+	TagURL:               {path: []string{"url", "original"}},
+	TagUA:                {path: []string{"user_agent", "original"}},
+	TagLatency:           {path: []string{"event", "duration"}, format: formatNanoseconds},
+	TagStatus:            {path: []string{"http", "response", "status_code"}},
+	TagResBody:           {path: []string{"http", "response", "body", "content"}},
+	TagQueryStringParams: {path: []string{"url", "query"}},
+	TagBody:              {path: []string{"http", "request", "body", "content"}},
+	TagBytesSent:         {path: []string{"http", "response", "body", "bytes"}},
+	TagBytesReceived:     {path: []string{"http", "request", "body", "bytes"}},
+	TagError:             {path: []string{"error", "message"}},
+	TagTraceID:           {path: []string{"trace", "id"}},
+	TagSpanID:            {path: []string{"span", "id"}},
+}
+
+// otelFields are the fields of ProfileOTel.
+var otelFields = map[string]fieldSpec{
+	TagReferer:           {path: []string{"http.request.header.referer"}},
+	TagProtocol:          {path: []string{"network.protocol.version"}, format: formatVersion},
+	TagPid:               {path: []string{"process.pid"}, format: formatNumber},
+	TagID:                {path: []string{"http.request.header.x-request-id"}},
+	TagIP:                {path: []string{"client.address"}},
+	TagIPs:               {path: []string{"http.request.header.x-forwarded-for"}},
+	TagHost:              {path: []string{"server.address"}},
+	TagMethod:            {path: []string{"http.request.method"}},
+	TagPath:              {path: []string{"url.path"}},
+	TagURL:               {path: []string{"url.full"}, format: formatAbsoluteURL},
+	TagUA:                {path: []string{"user_agent.original"}},
+	TagLatency:           {path: []string{"http.server.request.duration"}, format: formatSeconds},
+	TagStatus:            {path: []string{"http.response.status_code"}},
+	TagQueryStringParams: {path: []string{"url.query"}},
+	TagBytesSent:         {path: []string{"http.response.body.size"}},
+	TagBytesReceived:     {path: []string{"http.request.body.size"}},
+	TagRoute:             {path: []string{"http.route"}},
+	TagError:             {path: []string{"exception.message"}},
+	TagTraceID:           {path: []string{"trace_id"}},
+	TagSpanID:            {path: []string{"span_id"}},
+	TagTraceFlags:        {path: []string{"trace_flags"}},
+}
+
+// gcpFormat is the default format of ProfileGCP.
+var gcpFormat = []string{
+	TagMethod, TagURL, TagStatus, TagBytesSent, TagBytesReceived, TagUA, TagIP,
+	TagReferer, TagProtocol, TagLatency, TagTraceID, TagSpanID, TagTraceFlags,
+}
+
+// field returns the spec of the field written for tag.
+func (p Profile) field(tag string) fieldSpec {
+	var fields map[string]fieldSpec
+	switch p {
+	case ProfileGCP:
+		fields = gcpFields
+	case ProfileECS:
+		fields = ecsFields
+	case ProfileOTel:
+		fields = otelFields
+		if strings.HasPrefix(tag, TagHeader) {
+			return fieldSpec{path: []string{"http.request.header." + strings.ToLower(tag[len(TagHeader):])}}
+		}
+	}
+
+	if spec, ok := fields[tag]; ok {
+		return spec
+	}
+	return fieldSpec{path: []string{defaultKey(tag)}}
+}
+
+// defaultFormat returns the format used when Config.Format is not set.
+func (p Profile) defaultFormat() []string {
+	switch p {
+	case ProfileGCP:
+		return gcpFormat
+	}
+	return []string{TagTime, TagStatus, TagLatency, TagMethod, TagPath}
+}
+
+// defaultKey returns the key of a tag in ProfileFlat: the tag itself, or
+// the name after the prefix for a prefix tag.
+func defaultKey(tag string) string {
+	if i := strings.Index(tag, ":"); i >= 0 {
+		return tag[i+1:]
+	}
+	return tag
+}
+
+// TagAlias separates a tag from the key it is logged under in Format, so
+// that "header:X-Tenant-ID>tenant" logs the header as "tenant".
+const TagAlias = ">"
+
+// splitAlias splits a tag of Format into the tag and its alias.
+func splitAlias(tag string) (string, string) {
+	if i := strings.LastIndex(tag, TagAlias); i >= 0 {
+		return tag[:i], tag[i+len(TagAlias):]
+	}
+	return tag, ""
+}
+
+// field returns the tag of an entry of Format and the spec of its field,
+// taking the profile, FieldNames, an alias and FieldPrefix into account.
+func (cfg *Config) field(entry string) (string, fieldSpec) {
+	tag, alias := splitAlias(entry)
+	spec := cfg.Profile.field(tag)
+
+	if name, ok := cfg.FieldNames[tag]; ok {
+		spec = fieldSpec{path: []string{name}, format: spec.format}
+	}
+	if alias != "" {
+		spec = fieldSpec{path: []string{alias}, format: spec.format}
+	}
+	if cfg.FieldPrefix != "" {
+		path := append([]string{cfg.FieldPrefix + spec.path[0]}, spec.path[1:]...)
+		spec = fieldSpec{path: path, format: spec.format}
+	}
+
+	return tag, spec
+}
@@ -0,0 +1,205 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+func Test_ProfileGCP(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Profile:   ProfileGCP,
+		ProjectID: "my-project",
+		Output:    buf,
+		Clock:     clock,
+	}))
+
+	e.POST("/info.html", func(c echo.Context) error {
+		clock.Add(123 * time.Millisecond)
+		return c.String(http.StatusCreated, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader("test"))
+	req.Header.Set(echo.HeaderContentLength, "4")
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("Referer", "http://example.com/")
+	req.Header.Set(HeaderTraceparent, traceparent)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusCreated, res.Code)
+
+	require.Contains(t, buf.String(), `"httpRequest":{`+
+		`"requestMethod":"POST",`+
+		`"requestUrl":"http://example.com/info.html?test=true",`+
+		`"status":201,`+
+		`"responseSize":7,`+
+		`"requestSize":4,`+
+		`"userAgent":"test",`+
+		`"remoteIp":"192.0.2.1",`+
+		`"referer":"http://example.com/",`+
+		`"protocol":"HTTP/1.1",`+
+		`"latency":"0.123s"},`+
+		`"logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",`+
+		`"logging.googleapis.com/spanId":"00f067aa0ba902b7",`+
+		`"logging.googleapis.com/trace_sampled":true`)
+}
+
+func Test_ProfileGCP_RequestURL(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{Profile: ProfileGCP, Format: []string{TagURL}, Output: buf}))
+	e.GET("/info.html", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Servers receive the path and query in the request line
+	req := httptest.NewRequest(http.MethodGet, "/info.html?test=true", nil)
+	req.Host = "api.example.com"
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), `"httpRequest":{"requestUrl":"https://api.example.com/info.html?test=true"}`)
+}
+
+func Test_ProfileGCP_Validate(t *testing.T) {
+	require.Error(t, Config{Profile: ProfileGCP}.Validate())
+	require.NoError(t, Config{Profile: ProfileGCP, ProjectID: "my-project"}.Validate())
+	require.NoError(t, Config{Profile: ProfileGCP, Format: []string{TagStatus}}.Validate())
+}
+
+func Test_ProfileECS(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:              []string{TagMethod, TagPath, TagStatus, TagLatency, TagIP, TagUA, TagProtocol, TagBytesSent, TagTraceID},
+		ContextFormat:       []string{TagTraceID},
+		EnableContextLogger: true,
+		Profile:             ProfileECS,
+		Output:              buf,
+		Clock:               clock,
+	}))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		clock.Add(time.Millisecond)
+		FromContext(c).Info().Msg("handler")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set(HeaderTraceparent, traceparent)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"trace.id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	require.Contains(t, lines[1], `"http":{"request":{"method":"GET"},"response":{"status_code":200,"body":{"bytes":2}},"version":"1.1"},`+
+		`"url":{"path":"/info.html"},`+
+		`"event":{"duration":1000000},`+
+		`"client":{"ip":"192.0.2.1"},`+
+		`"user_agent":{"original":"test"},`+
+		`"trace":{"id":"4bf92f3577b34da6a3ce929d0e0e4736"}`)
+}
+
+func Test_ProfileOTel(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:  []string{TagMethod, TagRoute, TagStatus, TagLatency, TagIP, TagProtocol, TagHeader + "X-Tenant", TagTraceID, TagSpanID},
+		Profile: ProfileOTel,
+		Output:  buf,
+		Clock:   clock,
+	}))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		clock.Add(250 * time.Millisecond)
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set(HeaderTraceparent, traceparent)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	require.Contains(t, buf.String(), `"http.request.method":"GET",`+
+		`"http.route":"/info.html",`+
+		`"http.response.status_code":204,`+
+		`"http.server.request.duration":0.25,`+
+		`"client.address":"192.0.2.1",`+
+		`"network.protocol.version":"1.1",`+
+		`"http.request.header.x-tenant":"acme",`+
+		`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736",`+
+		`"span_id":"00f067aa0ba902b7"`)
+}
+
+func Test_FieldNames(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:              []string{TagStatus, TagUA, TagHeader + "X-Tenant-ID>tenant", "tenant>org", TagLatency + ">duration"},
+		ContextFormat:       []string{TagHeader + "X-Tenant-ID>tenant"},
+		EnableContextLogger: true,
+		FieldNames:          map[string]string{TagUA: "user_agent"},
+		FieldPrefix:         "http_",
+		Output:              buf,
+	}))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		FromContext(c).Info().Msg("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("X-Tenant-ID", "acme")
+	req.Header.Set("X-Tenant", "acme")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"http_tenant":"acme"`)
+	require.Contains(t, lines[1], `"http_status":200,"http_user_agent":"test","http_tenant":"acme","http_org":"acme","http_duration":`)
+}
+
+func Test_FieldGroup(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:     []string{TagStatus, TagMethod, "tenant"},
+		Profile:    ProfileECS,
+		FieldGroup: "access",
+		Output:     buf,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set("X-Tenant", "acme")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	require.Contains(t, buf.String(), `"access":{"http":{"response":{"status_code":404},"request":{"method":"GET"}},"tenant":"acme"}`)
+}
+
+func Test_FieldAlias_Validate(t *testing.T) {
+	require.NoError(t, Config{Format: []string{TagStatus + ">code"}, ContextFormat: []string{TagID + ">request_id"}}.Validate())
+	require.Error(t, Config{Format: []string{TagStatus + ">"}}.Validate())
+	require.Error(t, Config{ContextFormat: []string{TagID + ">"}}.Validate())
+}
@@ -0,0 +1,44 @@
+package zerologger
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// Field names of the logs of recovered panics.
+var (
+	PanicFieldName = "panic"
+	StackFieldName = "stack"
+)
+
+// recovered is a panic recovered from a handler.
+type recovered struct {
+	value interface{}
+	stack []byte
+}
+
+// callRecover calls the handler and recovers from its panic, returning it
+// as an error. http.ErrAbortHandler is not recovered, since it is used to
+// abort a response on purpose.
+func callRecover(next func() error, stackSize int) (rec *recovered, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if r == http.ErrAbortHandler {
+			panic(r)
+		}
+
+		stack := make([]byte, stackSize)
+		rec = &recovered{value: r, stack: stack[:runtime.Stack(stack, false)]}
+
+		var ok bool
+		if err, ok = r.(error); !ok {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return nil, next()
+}
@@ -0,0 +1,74 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_Recover(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:  []string{TagRoute, TagStatus, TagError},
+		Recover: true,
+		Output:  buf,
+	}))
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+
+	require.Contains(t, buf.String(), `"level":"error","route":"/panic","status":500,"error":"boom","panic":"boom","stack":"goroutine `)
+	require.Contains(t, buf.String(), "recover_test.go")
+}
+
+func Test_Recover_RePanic(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:  []string{TagStatus},
+		Recover: true,
+		RePanic: true,
+		Output:  buf,
+	}))
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	require.PanicsWithValue(t, "boom", func() {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	require.Contains(t, buf.String(), `"status":500,"panic":"boom"`)
+}
+
+func Test_Recover_AbortHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{Recover: true, Output: buf}))
+
+	e.GET("/abort", func(c echo.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+	require.Empty(t, buf.String())
+}
+
+func Test_Recover_Validate(t *testing.T) {
+	require.Error(t, Config{RePanic: true}.Validate())
+	require.NoError(t, Config{Recover: true, RePanic: true}.Validate())
+}
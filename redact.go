@@ -0,0 +1,365 @@
+package zerologger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// RedactedValue replaces values redacted with RedactMask.
+const RedactedValue = "[REDACTED]"
+
+// RedactMode selects how a redacted value is logged.
+type RedactMode int
+
+const (
+	// RedactMask replaces the value with RedactedValue.
+	RedactMask RedactMode = iota
+
+	// RedactHash replaces the value with a short keyed HMAC-SHA256, so
+	// equal values can still be correlated for as long as the key of
+	// Config.RedactHashKey is not rotated.
+	RedactHash
+
+	// RedactDrop removes the field, or the query parameter, from the log.
+	RedactDrop
+
+	// RedactPartial keeps the last 4 characters of the value.
+	RedactPartial
+)
+
+// RedactRule redacts the values of headers, query parameters, cookies, form
+// fields and locals with a matching name, and the values at the JSON paths
+// of request and response bodies.
+type RedactRule struct {
+	// Names are matched case-insensitively and may contain glob patterns,
+	// e.g. "authorization" or "x-*-token".
+	Names []string
+
+	// Pattern matches names with a regular expression.
+	Pattern *regexp.Regexp
+
+	// JSONPaths are dotted paths into JSON bodies, e.g. "user.password". A
+	// "*" segment matches any key, and arrays are matched element-wise.
+	JSONPaths []string
+
+	// Mode selects how the value is logged.
+	Mode RedactMode
+}
+
+// check returns an error if the rule matches nothing or cannot be used.
+func (rule RedactRule) check() error {
+	if len(rule.Names) == 0 && rule.Pattern == nil && len(rule.JSONPaths) == 0 {
+		return errors.New("no names, pattern or JSON paths")
+	}
+	if rule.Mode < RedactMask || rule.Mode > RedactPartial {
+		return fmt.Errorf("unknown mode %d", rule.Mode)
+	}
+	for _, name := range rule.Names {
+		if _, err := path.Match(name, ""); err != nil {
+			return fmt.Errorf("name %q: %v", name, err)
+		}
+	}
+	for _, p := range rule.JSONPaths {
+		for _, segment := range strings.Split(p, ".") {
+			if segment == "" {
+				return fmt.Errorf("JSON path %q has an empty segment", p)
+			}
+		}
+	}
+	return nil
+}
+
+// redactor applies the redaction rules of a config. A nil redactor leaves
+// every value untouched.
+type redactor struct {
+	rules []compiledRule
+	names bool
+	paths bool
+	key   func() []byte
+}
+
+type compiledRule struct {
+	names   []string
+	pattern *regexp.Regexp
+	paths   [][]string
+	mode    RedactMode
+}
+
+// processKey is the random key of RedactHash without Config.RedactHashKey.
+// It is shared by the middlewares and Transports of a process, so their
+// hashes can be correlated.
+var processKey struct {
+	once sync.Once
+	key  []byte
+}
+
+// newRedactor compiles the rules, or returns nil if there are none. Values
+// are hashed with key, or with the key of the process if it is nil.
+func newRedactor(rules []RedactRule, key func() []byte) *redactor {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if key == nil {
+		processKey.once.Do(func() {
+			processKey.key = make([]byte, 32)
+			randomBytes(processKey.key)
+		})
+		key = func() []byte { return processKey.key }
+	}
+
+	r := &redactor{key: key}
+	for _, rule := range rules {
+		cr := compiledRule{pattern: rule.Pattern, mode: rule.Mode}
+		for _, name := range rule.Names {
+			cr.names = append(cr.names, strings.ToLower(name))
+		}
+		for _, p := range rule.JSONPaths {
+			cr.paths = append(cr.paths, strings.Split(p, "."))
+		}
+		r.names = r.names || len(cr.names) > 0 || cr.pattern != nil
+		r.paths = r.paths || len(cr.paths) > 0
+		r.rules = append(r.rules, cr)
+	}
+	return r
+}
+
+// match returns the mode of the first rule matching name.
+func (r *redactor) match(name string) (RedactMode, bool) {
+	if r == nil || !r.names {
+		return 0, false
+	}
+
+	lower := strings.ToLower(name)
+	for _, rule := range r.rules {
+		for _, pattern := range rule.names {
+			if ok, _ := path.Match(pattern, lower); ok {
+				return rule.mode, true
+			}
+		}
+		if rule.pattern != nil && rule.pattern.MatchString(name) {
+			return rule.mode, true
+		}
+	}
+	return 0, false
+}
+
+// value returns the value to log in place of v, or false if the value must
+// be dropped.
+func (r *redactor) value(mode RedactMode, v string) (string, bool) {
+	switch {
+	case mode == RedactDrop:
+		return "", false
+	case v == "":
+		// Nothing to hide, and a mask would suggest there was a value
+		return v, true
+	}
+
+	switch mode {
+	case RedactHash:
+		mac := hmac.New(sha256.New, r.key())
+		mac.Write([]byte(v))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8]), true
+	case RedactPartial:
+		runes := []rune(v)
+		if len(runes) <= 4 {
+			return RedactedValue, true
+		}
+		return strings.Repeat("*", 4) + string(runes[len(runes)-4:]), true
+	default:
+		return RedactedValue, true
+	}
+}
+
+// valueOf is value for values of any type, which are redacted as their
+// default format.
+func (r *redactor) valueOf(mode RedactMode, v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		// The last characters of a number or an object are not
+		// meaningful, so they are masked completely
+		if mode == RedactPartial {
+			mode = RedactMask
+		}
+		s = fmt.Sprint(v)
+	}
+	return r.value(mode, s)
+}
+
+// tagHeader returns the canonical name of the request header read by tag,
+// or an empty string if the tag does not read a header.
+func tagHeader(cfg *Config, tag string) string {
+	switch tag {
+	case TagReferer:
+		return "Referer"
+	case TagID:
+		return http.CanonicalHeaderKey(cfg.RequestIDHeader)
+	case TagIPs:
+		return echo.HeaderXForwardedFor
+	case TagUA:
+		return "User-Agent"
+	}
+	if strings.HasPrefix(tag, TagHeader) {
+		return http.CanonicalHeaderKey(tag[len(TagHeader):])
+	}
+	return ""
+}
+
+// redactedStr returns a field writer logging the value under key, redacted
+// by r with mode.
+func redactedStr(r *redactor, key string, mode RedactMode, value func(lc *logContext) string) fieldWriter {
+	return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+		if v, keep := r.value(mode, value(lc)); keep {
+			return event.Str(key, v)
+		}
+		return event
+	}
+}
+
+// query redacts the values of the matching parameters of a raw query. The
+// query is left as is when nothing matches.
+func (r *redactor) query(raw string) string {
+	if r == nil || !r.names || raw == "" {
+		return raw
+	}
+
+	parts := strings.Split(raw, "&")
+	out := parts[:0]
+	changed := false
+	for _, part := range parts {
+		name, value := part, ""
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, value = part[:i], part[i+1:]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		mode, ok := r.match(name)
+		if !ok {
+			out = append(out, part)
+			continue
+		}
+		changed = true
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		if v, keep := r.value(mode, value); keep {
+			out = append(out, url.QueryEscape(name)+"="+url.QueryEscape(v))
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return strings.Join(out, "&")
+}
+
+// url returns the URL with the matching query parameters redacted.
+func (r *redactor) url(raw string) string {
+	if r == nil || !r.names {
+		return raw
+	}
+	i := strings.IndexByte(raw, '?')
+	if i < 0 {
+		return raw
+	}
+	query, fragment := raw[i+1:], ""
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query, fragment = query[:j], query[j:]
+	}
+	return raw[:i+1] + r.query(query) + fragment
+}
+
+// textParamPattern matches the name=value pairs of free text, such as the
+// query of a URL in an error message.
+var textParamPattern = regexp.MustCompile(`(^|[\s?&;,])([^\s?&;,=]+)=([^\s&;,"'<>#]*)`)
+
+// text redacts the values of the matching name=value pairs in free text.
+func (r *redactor) text(s string) string {
+	if r == nil || !r.names || strings.IndexByte(s, '=') < 0 {
+		return s
+	}
+	return textParamPattern.ReplaceAllStringFunc(s, func(pair string) string {
+		m := textParamPattern.FindStringSubmatch(pair)
+		name, value := m[2], m[3]
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		mode, ok := r.match(name)
+		if !ok {
+			return pair
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		if v, keep := r.value(mode, value); keep {
+			return m[1] + m[2] + "=" + url.QueryEscape(v)
+		}
+		return m[1]
+	})
+}
+
+// json redacts the values at the JSON paths of the rules. It returns false
+// if the data is not valid JSON.
+func (r *redactor) json(data []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+
+	for _, rule := range r.rules {
+		for _, p := range rule.paths {
+			doc = r.redactPath(doc, p, rule.mode)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, false
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), true
+}
+
+// redactPath redacts the values of doc at path and returns doc.
+func (r *redactor) redactPath(doc interface{}, path []string, mode RedactMode) interface{} {
+	switch v := doc.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = r.redactPath(v[i], path, mode)
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if len(path) > 1 {
+				v[key] = r.redactPath(child, path[1:], mode)
+				continue
+			}
+			if redacted, keep := r.valueOf(mode, child); keep {
+				v[key] = redacted
+			} else {
+				delete(v, key)
+			}
+		}
+	}
+	return doc
+}
@@ -0,0 +1,201 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_Redact(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format: []string{
+			"header:Authorization",
+			"header:X-Api-Token",
+			"cookie:session",
+			"query:card",
+			"form:password",
+			TagURL,
+			TagQueryStringParams,
+		},
+		ContextFormat:       []string{"header:Authorization"},
+		EnableContextLogger: true,
+		RedactHashKey:       func() []byte { return []byte("key") },
+		Redact: []RedactRule{
+			{Names: []string{"authorization", "password"}},
+			{Names: []string{"x-*-token"}, Mode: RedactHash},
+			{Pattern: regexp.MustCompile(`^sess`), Mode: RedactDrop},
+			{Names: []string{"card"}, Mode: RedactPartial},
+		},
+		Output: buf,
+	}))
+
+	e.POST("/info.html", func(c echo.Context) error {
+		FromContext(c).Info().Msg("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/info.html?card=4111111111111111&test=true", strings.NewReader("password=secret&user=me"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	req.Header.Set("X-Api-Token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "secret"})
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "4111111111111111")
+	require.NotContains(t, buf.String(), `"session"`)
+	require.Contains(t, buf.String(), `{"level":"info","Authorization":"[REDACTED]",`)
+	require.Contains(t, buf.String(), `"Authorization":"[REDACTED]",`+
+		`"X-Api-Token":"hmac:25cf3c44c8f39313",`+
+		`"card":"****1111",`+
+		`"password":"[REDACTED]",`+
+		`"url":"/info.html?card=%2A%2A%2A%2A1111&test=true",`+
+		`"queryParams":"card=%2A%2A%2A%2A1111&test=true"`)
+}
+
+func Test_Redact_Body(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format: []string{TagBody, TagResBody},
+		Redact: []RedactRule{
+			{JSONPaths: []string{"user.password", "tokens.*"}},
+			{JSONPaths: []string{"id"}, Mode: RedactDrop},
+		},
+		Output: buf,
+	}))
+
+	e.POST("/info.html", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, []map[string]interface{}{{"id": 1, "name": "<me>"}})
+	})
+
+	body := `{"user":{"name":"me","password":"secret","age":42},"tokens":[{"a":"secret"},{"b":"secret"}]}`
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	require.Contains(t, buf.String(), `"body":{"tokens":[{"a":"[REDACTED]"},{"b":"[REDACTED]"}],"user":{"age":42,"name":"me","password":"[REDACTED]"}}`)
+	require.Contains(t, buf.String(), `"resBody":[{"name":"<me>"}]`)
+}
+
+func Test_Redact_Partial(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format: []string{"query:name", TagBody},
+		Redact: []RedactRule{{Names: []string{"name"}, JSONPaths: []string{"*"}, Mode: RedactPartial}},
+		Output: buf,
+	}))
+
+	e.POST("/info.html", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	body := `{"card":4111111111111111,"name":"Zoë Müller"}`
+	req := httptest.NewRequest(http.MethodPost, "/info.html?name=J%C3%BCrgen", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), `"name":"****rgen"`)
+	require.Contains(t, buf.String(), `"body":{"card":"[REDACTED]","name":"****ller"}`)
+
+	buf.Reset()
+	req = httptest.NewRequest(http.MethodPost, "/info.html?name=%C3%BC%C3%BC%C3%BC%C3%BC%C3%BC", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), `"name":"****üüüü"`)
+}
+
+func Test_Redact_Truncated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:    []string{TagBody},
+		Redact:    []RedactRule{{JSONPaths: []string{"password"}}},
+		BodyLimit: 8,
+		Output:    buf,
+	}))
+
+	e.POST("/info.html", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader(`{"password":"secret"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, buf.String(), `"body":"[REDACTED]"`)
+}
+
+func Test_Redact_Fields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format: []string{TagError},
+		Redact: []RedactRule{
+			{Names: []string{"password", "token"}},
+			{Names: []string{"card"}, Mode: RedactPartial},
+			{Names: []string{"session"}, Mode: RedactDrop},
+		},
+		Output: buf,
+	}))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		Set(c, "password", "secret")
+		Set(c, "card", 4111111111111111)
+		Set(c, "session", "secret")
+		Set(c, "user", "me")
+		return errors.New(`Get "https://api.example.com/v1?token=secret&page=2": password=secret rejected`)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "session")
+	require.Contains(t, buf.String(), `"error":"Get \"https://api.example.com/v1?token=%5BREDACTED%5D&page=2\": password=%5BREDACTED%5D rejected"`)
+	require.Contains(t, buf.String(), `"password":"[REDACTED]","card":"[REDACTED]","user":"me"`)
+}
+
+func Test_Redact_Hash(t *testing.T) {
+	hash := func(key func() []byte) string {
+		buf := new(bytes.Buffer)
+		e := echo.New()
+		e.Use(New(Config{
+			Format:        []string{"header:X-Api-Token"},
+			Redact:        []RedactRule{{Names: []string{"x-api-token"}, Mode: RedactHash}},
+			RedactHashKey: key,
+			Output:        buf,
+		}))
+		req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+		req.Header.Set("X-Api-Token", "secret")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+		return buf.String()[strings.Index(buf.String(), "hmac:"):][:21]
+	}
+
+	// Without a key, the middlewares of a process share a random one
+	require.Equal(t, hash(nil), hash(nil))
+	require.NotEqual(t, hash(nil), hash(func() []byte { return []byte("key") }))
+	require.Equal(t, "hmac:25cf3c44c8f39313", hash(func() []byte { return []byte("key") }))
+}
+
+func Test_Redact_Validate(t *testing.T) {
+	require.NoError(t, Config{Redact: []RedactRule{{Names: []string{"x-*"}}}}.Validate())
+	require.Error(t, Config{Redact: []RedactRule{{}}}.Validate())
+	require.Error(t, Config{Redact: []RedactRule{{Names: []string{"["}}}}.Validate())
+	require.Error(t, Config{Redact: []RedactRule{{JSONPaths: []string{"user..password"}}}}.Validate())
+	require.Error(t, Config{Redact: []RedactRule{{Names: []string{"a"}, Mode: RedactMode(42)}}}.Validate())
+}
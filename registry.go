@@ -0,0 +1,161 @@
+package zerologger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// TagFunc adds the field of a custom tag to the log event of a request,
+// under key. The key is the name of the tag, unless an alias,
+// Config.FieldNames or Config.FieldPrefix change it.
+type TagFunc func(c Context, event *zerolog.Event, key string) *zerolog.Event
+
+// PrefixTagFunc adds the field of a custom prefix tag to the log event of a
+// request, under key. The name is the part of the tag after the prefix, so
+// for the prefix "claim:" and the tag "claim:sub" it is "sub". The key is
+// the name too, unless an alias, Config.FieldNames or Config.FieldPrefix
+// change it.
+type PrefixTagFunc func(c Context, event *zerolog.Event, key, name string) *zerolog.Event
+
+var registry = struct {
+	sync.RWMutex
+	tags     map[string]TagFunc
+	prefixes map[string]PrefixTagFunc
+}{
+	tags:     map[string]TagFunc{},
+	prefixes: map[string]PrefixTagFunc{},
+}
+
+// builtinTags are the tags handled by compileTag.
+var builtinTags = map[string]bool{
+	TagPid: true, TagTime: true, TagReferer: true, TagProtocol: true, TagID: true,
+	TagIP: true, TagIPs: true, TagHost: true, TagMethod: true, TagPath: true,
+	TagURL: true, TagUA: true, TagLatency: true, TagStatus: true, TagResBody: true,
+	TagQueryStringParams: true, TagBody: true, TagBytesSent: true,
+	TagBytesReceived: true, TagRoute: true, TagError: true, TagTraceID: true,
+	TagSpanID: true, TagParentSpanID: true, TagTraceFlags: true, TagTraceState: true,
+	TagGoroutines: true,
+}
+
+// builtinPrefixes are the prefix tags handled by compileTag.
+var builtinPrefixes = []string{TagHeader, TagLocals, TagQuery, TagForm, TagCookie}
+
+// RegisterTag adds a custom tag that can be used in Config.Format like any
+// built-in tag. Tags must be registered before the middleware is created.
+// It panics if the name is empty or already taken.
+func RegisterTag(name string, fn TagFunc) {
+	if name == "" || fn == nil {
+		panic("zerologger: RegisterTag requires a name and a function")
+	}
+	if builtinTags[name] || isPrefixed(name, builtinPrefixes) {
+		panic(fmt.Sprintf("zerologger: tag %q is built in", name))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.tags[name]; ok {
+		panic(fmt.Sprintf("zerologger: tag %q is already registered", name))
+	}
+	registry.tags[name] = fn
+}
+
+// RegisterPrefixTag adds a custom prefix tag, such as "claim:", that can be
+// used in Config.Format like "header:". The prefix must end with a colon.
+// Tags must be registered before the middleware is created. It panics if
+// the prefix is invalid or already taken.
+func RegisterPrefixTag(prefix string, fn PrefixTagFunc) {
+	if len(prefix) < 2 || !strings.HasSuffix(prefix, ":") || fn == nil {
+		panic("zerologger: RegisterPrefixTag requires a prefix ending with ':' and a function")
+	}
+	for _, p := range builtinPrefixes {
+		if p == prefix {
+			panic(fmt.Sprintf("zerologger: prefix tag %q is built in", prefix))
+		}
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.prefixes[prefix]; ok {
+		panic(fmt.Sprintf("zerologger: prefix tag %q is already registered", prefix))
+	}
+	registry.prefixes[prefix] = fn
+}
+
+// compileCustomTag returns the field writer for a registered tag, writing
+// under key, or nil if no registered tag matches.
+func compileCustomTag(tag, key string) fieldWriter {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if fn, ok := registry.tags[tag]; ok {
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			return fn(lc.c, event, key)
+		}
+	}
+
+	// Prefer the longest prefix when registered prefixes overlap
+	var match string
+	for prefix := range registry.prefixes {
+		if strings.HasPrefix(tag, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match == "" {
+		return nil
+	}
+
+	fn, name := registry.prefixes[match], tag[len(match):]
+	return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+		return fn(lc.c, event, key, name)
+	}
+}
+
+// checkTag returns an error if the tag of a Format entry is neither built in
+// nor registered, if it is a prefix tag without a name, or if its alias is
+// empty.
+func checkTag(entry string) error {
+	tag, alias := splitAlias(entry)
+	if tag != entry && alias == "" {
+		return fmt.Errorf("tag %q has no alias after %q", entry, TagAlias)
+	}
+
+	if builtinTags[tag] {
+		return nil
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if _, ok := registry.tags[tag]; ok {
+		return nil
+	}
+
+	prefixes := append([]string{}, builtinPrefixes...)
+	for prefix := range registry.prefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(tag, prefix) {
+			if tag == prefix {
+				return fmt.Errorf("tag %q has no name after the prefix", tag)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown tag %q", tag)
+}
+
+// isPrefixed reports whether tag starts with one of the prefixes.
+func isPrefixed(tag string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(tag, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+// Tags are registered globally, so register them once for -count runs
+func init() {
+	RegisterTag("tenant", func(c Context, e *zerolog.Event, key string) *zerolog.Event {
+		return e.Str(key, c.Header("X-Tenant"))
+	})
+	RegisterPrefixTag("param:", func(c Context, e *zerolog.Event, key, name string) *zerolog.Event {
+		return e.Str(key, c.Param(name))
+	})
+}
+
+func Test_RegisterTag(t *testing.T) {
+	buf, e := testEcho(TagStatus, "tenant")
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set("X-Tenant", "acme")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Contains(t, string(data), `"tenant":"acme"`)
+
+	require.Panics(t, func() {
+		RegisterTag("tenant", func(c Context, e *zerolog.Event, key string) *zerolog.Event { return e })
+	})
+	require.Panics(t, func() {
+		RegisterTag(TagStatus, func(c Context, e *zerolog.Event, key string) *zerolog.Event { return e })
+	})
+	require.Panics(t, func() { RegisterTag("", nil) })
+}
+
+func Test_RegisterPrefixTag(t *testing.T) {
+	buf, e := testEcho("param:id")
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, "id", "42"))
+
+	require.Panics(t, func() {
+		RegisterPrefixTag("param:", func(c Context, e *zerolog.Event, key, name string) *zerolog.Event { return e })
+	})
+	require.Panics(t, func() {
+		RegisterPrefixTag(TagHeader, func(c Context, e *zerolog.Event, key, name string) *zerolog.Event { return e })
+	})
+	require.Panics(t, func() { RegisterPrefixTag("param", nil) })
+}
+
+func Test_RegisterTag_Key(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:      []string{"tenant", "param:id>user"},
+		FieldNames:  map[string]string{"tenant": "org"},
+		FieldPrefix: "app.",
+		Output:      buf,
+	}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("X-Tenant", "acme")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), `"app.org":"acme","app.user":"42"`)
+}
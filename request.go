@@ -0,0 +1,291 @@
+package zerologger
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Context gives access to a request and its response, whatever the
+// framework serving them. Tags, LevelFunc and Sampler read requests
+// through it, so the same Config logs the same fields with Echo, net/http,
+// Gin and Fiber.
+type Context interface {
+	// Method returns the method of the request.
+	Method() string
+
+	// Scheme returns "http" or "https".
+	Scheme() string
+
+	// Host returns the host of the request.
+	Host() string
+
+	// Path returns the path of the URL.
+	Path() string
+
+	// URL returns the URL of the request as received, usually the path and
+	// the query.
+	URL() string
+
+	// RequestURI returns the path and the query of the URL.
+	RequestURI() string
+
+	// Proto returns the protocol of the request, e.g. "HTTP/1.1".
+	Proto() string
+
+	// Header returns the first value of a request header.
+	Header(name string) string
+
+	// RealIP returns the address of the client.
+	RealIP() string
+
+	// Route returns the route pattern matched by the request, e.g.
+	// "/users/:id".
+	Route() string
+
+	// Param returns the value of a parameter of the route.
+	Param(name string) string
+
+	// QueryString returns the raw query of the URL.
+	QueryString() string
+
+	// QueryParam returns the value of a query parameter.
+	QueryParam(name string) string
+
+	// FormValue returns the value of a form field.
+	FormValue(name string) string
+
+	// Cookie returns the value of a cookie.
+	Cookie(name string) string
+
+	// Get returns a value stored for the request, such as with
+	// echo.Context.Set.
+	Get(key string) interface{}
+
+	// Context returns the context of the request.
+	Context() context.Context
+
+	// Status returns the status of the response.
+	Status() int
+
+	// Size returns the number of bytes of the response body.
+	Size() int64
+
+	// ResponseHeader returns the first value of a response header.
+	ResponseHeader(name string) string
+}
+
+// Adapter is the Context of a framework, with the hooks the middleware
+// uses to change the request and the response. Adapters of other
+// frameworks implement it and call Middleware.Serve.
+type Adapter interface {
+	Context
+
+	// Set stores a value for the request.
+	Set(key string, value interface{})
+
+	// SetRequestHeader sets a header of the request.
+	SetRequestHeader(name, value string)
+
+	// SetResponseHeader sets a header of the response.
+	SetResponseHeader(name, value string)
+
+	// SetContext replaces the context of the request.
+	SetContext(ctx context.Context)
+
+	// Body returns the request body, and SetBody replaces the body read by
+	// the handler.
+	Body() io.ReadCloser
+	SetBody(body io.ReadCloser)
+
+	// Writer returns the writer of the response body, and SetWriter
+	// replaces the writer used by the handler.
+	Writer() http.ResponseWriter
+	SetWriter(w http.ResponseWriter)
+
+	// Next calls the handler.
+	Next() error
+
+	// Error responds to an error returned by the handler, or to a panic
+	// recovered from it.
+	Error(err error)
+}
+
+// echoContext is the Adapter of Echo.
+type echoContext struct {
+	ctx  echo.Context
+	next echo.HandlerFunc
+}
+
+var _ Adapter = (*echoContext)(nil)
+
+func (c *echoContext) Method() string {
+	return c.ctx.Request().Method
+}
+
+func (c *echoContext) Scheme() string {
+	return c.ctx.Scheme()
+}
+
+func (c *echoContext) Host() string {
+	return c.ctx.Request().Host
+}
+
+func (c *echoContext) Path() string {
+	return c.ctx.Request().URL.Path
+}
+
+func (c *echoContext) URL() string {
+	return c.ctx.Request().URL.String()
+}
+
+func (c *echoContext) RequestURI() string {
+	return c.ctx.Request().URL.RequestURI()
+}
+
+func (c *echoContext) Proto() string {
+	return c.ctx.Request().Proto
+}
+
+func (c *echoContext) Header(name string) string {
+	return headerValue(c.ctx.Request().Header, name)
+}
+
+func (c *echoContext) RealIP() string {
+	return c.ctx.RealIP()
+}
+
+func (c *echoContext) Route() string {
+	return c.ctx.Path()
+}
+
+func (c *echoContext) Param(name string) string {
+	return c.ctx.Param(name)
+}
+
+func (c *echoContext) QueryString() string {
+	return c.ctx.Request().URL.RawQuery
+}
+
+func (c *echoContext) QueryParam(name string) string {
+	return queryValue(c.ctx.QueryString(), name)
+}
+
+func (c *echoContext) FormValue(name string) string {
+	return c.ctx.FormValue(name)
+}
+
+func (c *echoContext) Cookie(name string) string {
+	if cookie, err := c.ctx.Cookie(name); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (c *echoContext) Get(key string) interface{} {
+	return c.ctx.Get(key)
+}
+
+func (c *echoContext) Context() context.Context {
+	return c.ctx.Request().Context()
+}
+
+func (c *echoContext) Status() int {
+	return c.ctx.Response().Status
+}
+
+func (c *echoContext) Size() int64 {
+	return c.ctx.Response().Size
+}
+
+func (c *echoContext) ResponseHeader(name string) string {
+	return c.ctx.Response().Header().Get(name)
+}
+
+func (c *echoContext) Set(key string, value interface{}) {
+	c.ctx.Set(key, value)
+}
+
+func (c *echoContext) SetRequestHeader(name, value string) {
+	c.ctx.Request().Header.Set(name, value)
+}
+
+func (c *echoContext) SetResponseHeader(name, value string) {
+	c.ctx.Response().Header().Set(name, value)
+}
+
+func (c *echoContext) SetContext(ctx context.Context) {
+	c.ctx.SetRequest(c.ctx.Request().WithContext(ctx))
+}
+
+func (c *echoContext) Body() io.ReadCloser {
+	return c.ctx.Request().Body
+}
+
+func (c *echoContext) SetBody(body io.ReadCloser) {
+	c.ctx.Request().Body = body
+}
+
+func (c *echoContext) Writer() http.ResponseWriter {
+	return c.ctx.Response().Writer
+}
+
+func (c *echoContext) SetWriter(w http.ResponseWriter) {
+	c.ctx.Response().Writer = w
+}
+
+func (c *echoContext) Next() error {
+	return c.next(c.ctx)
+}
+
+func (c *echoContext) Error(err error) {
+	c.ctx.Error(err)
+}
+
+// headerValue returns the first value of a header, like http.Header.Get.
+// Names compiled from the config are canonical already, so the header is
+// looked up directly, and only looked up again if its name was not.
+func headerValue(h http.Header, name string) string {
+	v, ok := h[name]
+	if !ok {
+		if key := http.CanonicalHeaderKey(name); key != name {
+			v = h[key]
+		}
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+// queryValue returns the first value of name in a raw query, like
+// url.Values.Get does after url.ParseQuery, without parsing the whole query.
+func queryValue(query, name string) string {
+	for query != "" {
+		pair := query
+		if i := strings.IndexByte(query, '&'); i >= 0 {
+			pair, query = query[:i], query[i+1:]
+		} else {
+			query = ""
+		}
+		if strings.IndexByte(pair, ';') >= 0 {
+			continue
+		}
+
+		key, value := pair, ""
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			key, value = pair[:i], pair[i+1:]
+		}
+		if k, err := url.QueryUnescape(key); err != nil || k != name {
+			continue
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package zerologger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_queryValue(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"a=1",
+		"a=1&a=2&b=3",
+		"b=3&a",
+		"a%3D=1&a=%E2%82%AC+x",
+		"a=%zz&a=2",
+		"a=1;b&a=2",
+		"&&a=&b=2",
+	} {
+		values, _ := url.ParseQuery(query)
+		for _, name := range []string{"a", "b", "a=", "c"} {
+			require.Equal(t, values.Get(name), queryValue(query, name), "%q in %q", name, query)
+		}
+	}
+}
+
+func Test_headerValue(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Request-Id", "1")
+	h["Empty"] = nil
+
+	require.Equal(t, "1", headerValue(h, "X-Request-Id"))
+	require.Equal(t, "1", headerValue(h, "x-request-id"))
+	require.Equal(t, "", headerValue(h, "Empty"))
+	require.Equal(t, "", headerValue(h, "Missing"))
+}
+
+func Test_serve_Allocs(t *testing.T) {
+	// Requests may only pay for propagation once there is a Transport
+	n := atomic.SwapInt32(&transports, 0)
+	defer atomic.StoreInt32(&transports, n)
+
+	e := echo.New()
+	e.Use(New(Config{Output: io.Discard}))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	allocs := func(header http.Header) float64 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		res := httptest.NewRecorder()
+		return testing.AllocsPerRun(100, func() {
+			e.ServeHTTP(res, req)
+		})
+	}
+
+	proxied := http.Header{
+		"X-Request-Id": {"incoming"},
+		"Traceparent":  {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+	require.Equal(t, allocs(nil), allocs(proxied))
+}
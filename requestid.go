@@ -0,0 +1,150 @@
+package zerologger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requestIDKey is the request store key of the request ID.
+const requestIDKey = "zerologger.requestID"
+
+// requestIDContextKey is the context.Context key of the request ID.
+type requestIDContextKey struct{}
+
+// defaultRequestIDHeader is the canonical name of the default
+// Config.RequestIDHeader, which RequestID reads outside of the middleware.
+var defaultRequestIDHeader = http.CanonicalHeaderKey(echo.HeaderXRequestID)
+
+// RequestID returns the ID of the current request. It is the ID received
+// in Config.RequestIDHeader, or the ID generated by the middleware when
+// Config.RequestIDGenerator is set. Outside of the middleware, it is the
+// value of the X-Request-ID header.
+func RequestID(ctx echo.Context) string {
+	if id, ok := ctx.Get(requestIDKey).(string); ok {
+		return id
+	}
+	return ctx.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// RequestIDFromContext returns the request ID stored in a request context by
+// the middleware, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// setRequestID stores the ID of the request for RequestID and in the
+// request context. With Config.RequestIDGenerator, it makes sure the
+// request has an ID, and exposes it on the request and response headers
+// too. Otherwise the ID of the client is only stored where it cannot be
+// read from the request already: for RequestID with a custom header, and
+// in the request context once a Transport may propagate it.
+func setRequestID(c Adapter, cfg *Config) {
+	if cfg.RequestIDGenerator == nil {
+		if cfg.RequestIDHeader == defaultRequestIDHeader && !propagating() {
+			return
+		}
+		if id := c.Header(cfg.RequestIDHeader); id != "" {
+			if cfg.RequestIDHeader != defaultRequestIDHeader {
+				c.Set(requestIDKey, id)
+			}
+			if propagating() {
+				c.SetContext(context.WithValue(c.Context(), requestIDContextKey{}, id))
+			}
+		}
+		return
+	}
+
+	id := c.Header(cfg.RequestIDHeader)
+
+	if id == "" || cfg.IgnoreClientRequestID {
+		id = cfg.RequestIDGenerator()
+		c.SetRequestHeader(cfg.RequestIDHeader, id)
+	}
+
+	c.SetResponseHeader(cfg.RequestIDHeader, id)
+	c.Set(requestIDKey, id)
+	c.SetContext(context.WithValue(c.Context(), requestIDContextKey{}, id))
+}
+
+// UUIDv4 returns a random UUID, as defined in RFC 4122.
+func UUIDv4() string {
+	var u [16]byte
+	randomBytes(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// UUIDv7 returns a UUID that starts with the current Unix time in
+// milliseconds, so IDs sort by creation time.
+func UUIDv7() string {
+	var u [16]byte
+	randomBytes(u[6:])
+	putMillis(u[:6], time.Now())
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// ULID returns a Universally Unique Lexicographically Sortable Identifier,
+// made of the current Unix time in milliseconds and 80 random bits.
+func ULID() string {
+	var u [16]byte
+	randomBytes(u[6:])
+	putMillis(u[:6], time.Now())
+	return encodeULID(u)
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// putMillis writes the 48 bit Unix time in milliseconds of t into b.
+func putMillis(b []byte, t time.Time) {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(b, ms[2:])
+}
+
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// crockford is the Base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID encodes the 128 bits of u as 26 Base32 characters, most
+// significant first. The first character only holds 3 bits.
+func encodeULID(u [16]byte) string {
+	var buf [26]byte
+	for i := range buf {
+		var v byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			v <<= 1
+			if bit >= 0 && u[bit/8]&(0x80>>uint(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		buf[i] = crockford[v]
+	}
+	return string(buf[:])
+}
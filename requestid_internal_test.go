@@ -0,0 +1,21 @@
+package zerologger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_encodeULID(t *testing.T) {
+	var u [16]byte
+	require.Equal(t, "00000000000000000000000000", encodeULID(u))
+
+	for i := range u {
+		u[i] = 0xff
+	}
+	require.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(u))
+
+	// Timestamp of the example in the ULID specification
+	u = [16]byte{0x01, 0x56, 0x3e, 0x3a, 0xb5, 0xd3}
+	require.Equal(t, "01ARZ3NDEK0000000000000000", encodeULID(u))
+}
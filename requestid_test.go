@@ -0,0 +1,107 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+func Test_RequestIDGenerators(t *testing.T) {
+	uuid4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuid7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+	require.Regexp(t, uuid4, UUIDv4())
+	require.Regexp(t, uuid7, UUIDv7())
+	require.Regexp(t, ulid, ULID())
+	require.NotEqual(t, UUIDv4(), UUIDv4())
+	require.NotEqual(t, ULID(), ULID())
+}
+
+func testRequestID(t *testing.T, cfg Config) (*bytes.Buffer, *echo.Echo, *string) {
+	buf := new(bytes.Buffer)
+	cfg.Format = []string{TagID}
+	cfg.Output = buf
+
+	e := echo.New()
+	e.Use(New(cfg))
+
+	id := new(string)
+	e.GET("/", func(c echo.Context) error {
+		*id = RequestID(c)
+		require.Equal(t, *id, RequestIDFromContext(c.Request().Context()))
+		return c.NoContent(http.StatusOK)
+	})
+
+	return buf, e, id
+}
+
+func Test_RequestID_Generate(t *testing.T) {
+	buf, e, id := testRequestID(t, Config{
+		RequestIDGenerator: func() string { return "generated" },
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, "generated", *id)
+	require.Equal(t, "generated", res.Header().Get(echo.HeaderXRequestID))
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagID, "generated"))
+
+	// Trust the client by default
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "client")
+	res = httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ = io.ReadAll(buf)
+	require.Equal(t, "client", *id)
+	require.Equal(t, "client", res.Header().Get(echo.HeaderXRequestID))
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagID, "client"))
+}
+
+func Test_RequestID_IgnoreClient(t *testing.T) {
+	buf, e, id := testRequestID(t, Config{
+		RequestIDGenerator:    func() string { return "generated" },
+		RequestIDHeader:       "X-Correlation-ID",
+		IgnoreClientRequestID: true,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "client")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, "generated", *id)
+	require.Equal(t, "generated", res.Header().Get("X-Correlation-ID"))
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagID, "generated"))
+
+	err := Config{IgnoreClientRequestID: true}.Validate()
+	require.Error(t, err)
+}
+
+func Test_RequestID_Header(t *testing.T) {
+	e := echo.New()
+	e.Use(New(Config{Format: []string{TagID}, RequestIDHeader: "X-Correlation-ID", Output: io.Discard}))
+
+	var id string
+	e.GET("/", func(c echo.Context) error {
+		id = RequestID(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "client")
+	req.Header.Set(echo.HeaderXRequestID, "other")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, "client", id)
+}
@@ -0,0 +1,156 @@
+package zerologger
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SampleRateFieldName is the field name of the sample rate of sampled logs.
+var SampleRateFieldName = "sampleRate"
+
+// Sampler decides whether the log of a request is written. It is asked
+// before the handler runs, so the request body is not captured for logs
+// that are dropped, and the Context has no response yet. Dropped requests
+// that fail, return a server error or are slow are logged anyway, without
+// their bodies and with a rate of 0, since the sampler counts them in the
+// rate of its next log.
+type Sampler interface {
+	// Sample reports whether the request is logged and, if so, how many
+	// requests its log stands for.
+	Sample(c Context) (rate int, keep bool)
+}
+
+// RatioSampler logs a fixed ratio of the requests, chosen at random. The
+// rate of a log is the number of requests since the previous log.
+type RatioSampler struct {
+	// Ratio is the fraction of requests logged, between 0 and 1.
+	Ratio float64
+
+	dropped uint64
+}
+
+// Sample implements the Sampler interface.
+func (s *RatioSampler) Sample(Context) (int, bool) {
+	if s.Ratio <= 0 {
+		return 0, false
+	}
+	if s.Ratio < 1 && rand.Float64() >= s.Ratio {
+		atomic.AddUint64(&s.dropped, 1)
+		return 0, false
+	}
+	return int(atomic.SwapUint64(&s.dropped, 0)) + 1, true
+}
+
+// RouteSampler logs at most PerSecond requests per second for each route,
+// so a busy endpoint cannot drown out the others. The rate of a log is the
+// number of requests of its route since the previous log of the route.
+type RouteSampler struct {
+	// PerSecond is the number of requests logged per route and second.
+	PerSecond int
+
+	// Clock is the source of time. Optional. Default: SystemClock
+	Clock Clock
+
+	mu     sync.Mutex
+	routes map[string]*routeWindow
+}
+
+type routeWindow struct {
+	start   time.Time
+	seen    int
+	dropped int
+}
+
+// Sample implements the Sampler interface.
+func (s *RouteSampler) Sample(c Context) (int, bool) {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.routes == nil {
+		s.routes = map[string]*routeWindow{}
+	}
+	route := c.Route()
+	w, ok := s.routes[route]
+	if !ok {
+		w = &routeWindow{start: now}
+		s.routes[route] = w
+	}
+
+	if now.Sub(w.start) >= time.Second {
+		w.start, w.seen = now, 0
+	}
+
+	w.seen++
+	if w.seen > s.PerSecond {
+		w.dropped++
+		return 0, false
+	}
+	rate := w.dropped + 1
+	w.dropped = 0
+	return rate, true
+}
+
+// BurstSampler logs the first Burst requests of every Period, and passes
+// the rest to Next. Requests beyond the burst are dropped if Next is nil,
+// and counted in the rate of the next log of a burst.
+type BurstSampler struct {
+	// Burst is the number of requests logged per period.
+	Burst int
+
+	// Period is the length of a period.
+	Period time.Duration
+
+	// Next samples the requests beyond the burst. Optional.
+	Next Sampler
+
+	// Clock is the source of time. Optional. Default: SystemClock
+	Clock Clock
+
+	mu      sync.Mutex
+	reset   time.Time
+	count   int
+	dropped int
+}
+
+// Sample implements the Sampler interface.
+func (s *BurstSampler) Sample(c Context) (int, bool) {
+	now := clockOrSystem(s.Clock).Now()
+
+	s.mu.Lock()
+	if !now.Before(s.reset) {
+		s.reset, s.count = now.Add(s.Period), 0
+	}
+	s.count++
+	if s.count <= s.Burst {
+		rate := s.dropped + 1
+		s.dropped = 0
+		s.mu.Unlock()
+		return rate, true
+	}
+	if s.Next == nil {
+		s.dropped++
+		s.mu.Unlock()
+		return 0, false
+	}
+	s.mu.Unlock()
+
+	return s.Next.Sample(c)
+}
+
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// failed reports whether a request returned an error or a server error, so
+// it must be logged whatever the sampler decides.
+func failed(lc *logContext) bool {
+	return lc.err != nil || lc.c.Status() >= http.StatusInternalServerError
+}
@@ -0,0 +1,210 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+func testSampler(cfg Config) (*bytes.Buffer, *echo.Echo) {
+	buf := new(bytes.Buffer)
+	cfg.Format = []string{TagRoute, TagStatus}
+	cfg.Output = buf
+	e := echo.New()
+	e.Use(New(cfg))
+
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/a", handler)
+	e.GET("/b", handler)
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("error")
+	})
+	e.GET("/unavailable", func(c echo.Context) error {
+		return c.NoContent(http.StatusServiceUnavailable)
+	})
+	return buf, e
+}
+
+func serve(e *echo.Echo, path string) {
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+func Test_RatioSampler(t *testing.T) {
+	buf, e := testSampler(Config{Sampler: &RatioSampler{Ratio: 0}})
+	serve(e, "/a")
+	serve(e, "/error")
+	serve(e, "/unavailable")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"route":"/error","status":500,"sampleRate":0`)
+	require.Contains(t, lines[1], `"route":"/unavailable","status":503,"sampleRate":0`)
+
+	buf, e = testSampler(Config{Sampler: &RatioSampler{Ratio: 1}})
+	serve(e, "/a")
+	require.Contains(t, buf.String(), `"route":"/a","status":200,"sampleRate":1`)
+}
+
+func Test_RouteSampler(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+	buf, e := testSampler(Config{Sampler: &RouteSampler{PerSecond: 2, Clock: clock}})
+
+	for i := 0; i < 5; i++ {
+		serve(e, "/a")
+	}
+	serve(e, "/b")
+	require.Equal(t, 2, strings.Count(buf.String(), `"route":"/a"`))
+	require.Equal(t, 1, strings.Count(buf.String(), `"route":"/b"`))
+
+	buf.Reset()
+	clock.Add(time.Second)
+	serve(e, "/a")
+	serve(e, "/b")
+	require.Contains(t, buf.String(), `"route":"/a","status":200,"sampleRate":4`)
+	require.Contains(t, buf.String(), `"route":"/b","status":200,"sampleRate":1`)
+
+	// The drops of the first window are counted after an idle gap too
+	buf.Reset()
+	for i := 0; i < 3; i++ {
+		serve(e, "/b")
+	}
+	clock.Add(time.Minute)
+	serve(e, "/b")
+	require.Contains(t, buf.String(), `"route":"/b","status":200,"sampleRate":3`)
+}
+
+func Test_RatioSampler_Rate(t *testing.T) {
+	buf, e := testSampler(Config{Sampler: &RatioSampler{Ratio: 0.3}})
+
+	const requests = 1000
+	for i := 0; i < requests; i++ {
+		serve(e, "/a")
+	}
+
+	// The rates add up to the requests, except those after the last log
+	var sum int
+	for _, m := range regexp.MustCompile(`"sampleRate":(\d+)`).FindAllStringSubmatch(buf.String(), -1) {
+		rate, _ := strconv.Atoi(m[1])
+		sum += rate
+	}
+	require.LessOrEqual(t, sum, requests)
+	require.Greater(t, sum, requests-50)
+}
+
+type samplerFunc func(c Context) (int, bool)
+
+func (f samplerFunc) Sample(c Context) (int, bool) {
+	return f(c)
+}
+
+func Test_Sampler_BeforeHandler(t *testing.T) {
+	var sampled bool
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format: []string{TagRoute, TagBody},
+		Sampler: samplerFunc(func(Context) (int, bool) {
+			sampled = true
+			return 0, false
+		}),
+		Output: buf,
+	}))
+	e.POST("/a", func(c echo.Context) error {
+		require.True(t, sampled)
+		return c.NoContent(http.StatusOK)
+	})
+	e.POST("/error", func(c echo.Context) error {
+		return errors.New("error")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/a", strings.NewReader(`{"a":1}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Empty(t, buf.String())
+
+	// Failed requests are logged anyway, without the body
+	req = httptest.NewRequest(http.MethodPost, "/error", strings.NewReader(`{"a":1}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Contains(t, buf.String(), `"route":"/error","sampleRate":0`)
+	require.NotContains(t, buf.String(), `"body"`)
+}
+
+func Test_BurstSampler(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+	buf, e := testSampler(Config{Sampler: &BurstSampler{Burst: 2, Period: time.Second, Clock: clock}})
+
+	for i := 0; i < 4; i++ {
+		serve(e, "/a")
+	}
+	require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	clock.Add(time.Second)
+	serve(e, "/a")
+	require.Equal(t, 3, strings.Count(buf.String(), "\n"))
+
+	buf, e = testSampler(Config{Sampler: &BurstSampler{Burst: 1, Period: time.Second, Clock: clock, Next: &RatioSampler{Ratio: 1}}})
+	serve(e, "/a")
+	serve(e, "/a")
+	require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+}
+
+func Test_Sampler_Forced(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+	buf, e := testSampler(Config{Sampler: &BurstSampler{Burst: 1, Period: time.Second, Clock: clock}})
+
+	// The failed request is logged and counted in the rate of the next log
+	serve(e, "/a")
+	serve(e, "/error")
+	clock.Add(time.Second)
+	serve(e, "/a")
+
+	var sum int
+	for _, m := range regexp.MustCompile(`"sampleRate":(\d+)`).FindAllStringSubmatch(buf.String(), -1) {
+		rate, _ := strconv.Atoi(m[1])
+		sum += rate
+	}
+	require.Equal(t, 3, strings.Count(buf.String(), "\n"))
+	require.Equal(t, 3, sum)
+}
+
+func Test_Sampler_Slow(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:        []string{TagRoute},
+		Sampler:       &RatioSampler{Ratio: 0},
+		SlowThreshold: time.Second,
+		Clock:         clock,
+		Output:        buf,
+	}))
+
+	e.GET("/slow", func(c echo.Context) error {
+		clock.Add(2 * time.Second)
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve(e, "/slow")
+	serve(e, "/fast")
+	require.Contains(t, buf.String(), `"route":"/slow"`)
+	require.NotContains(t, buf.String(), `"route":"/fast"`)
+}
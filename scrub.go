@@ -0,0 +1,174 @@
+package zerologger
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// PIIKind names a kind of personal data found by a Scrubber.
+type PIIKind string
+
+// Kinds of personal data detected by NewScrubber.
+const (
+	PIIBearer PIIKind = "bearer"
+	PIIJWT    PIIKind = "jwt"
+	PIIEmail  PIIKind = "email"
+	PIIIBAN   PIIKind = "iban"
+	PIICard   PIIKind = "card"
+)
+
+// detector finds one kind of personal data. The replacement is only made
+// if valid accepts the match, so that random numbers are not taken for
+// card numbers.
+type detector struct {
+	kind        PIIKind
+	hint        func(s string) bool
+	pattern     *regexp.Regexp
+	valid       func(match string) bool
+	replacement string
+	count       uint64
+}
+
+// Scrubber replaces personal data in logged values, such as email addresses
+// and card numbers, and counts the replacements. It is safe for concurrent
+// use.
+type Scrubber struct {
+	detectors []*detector
+}
+
+// NewScrubber returns a Scrubber for the given kinds of personal data, or
+// for all of them if none are given.
+func NewScrubber(kinds ...PIIKind) *Scrubber {
+	all := []*detector{
+		{
+			kind:        PIIBearer,
+			hint:        func(s string) bool { return strings.Contains(strings.ToLower(s), "bearer") },
+			pattern:     regexp.MustCompile(`(?i)\b(bearer(?:\s|\+|%20)+)[A-Za-z0-9._~+/-]+=*`),
+			replacement: "${1}[TOKEN]",
+		},
+		{
+			kind:        PIIJWT,
+			hint:        func(s string) bool { return strings.Contains(s, "eyJ") },
+			pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			replacement: "[JWT]",
+		},
+		{
+			kind:        PIIEmail,
+			hint:        func(s string) bool { return strings.Contains(s, "@") || strings.Contains(s, "%40") },
+			pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+(?:@|%40)[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+			replacement: "[EMAIL]",
+		},
+		{
+			kind:        PIIIBAN,
+			hint:        hasDigit,
+			pattern:     regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+			valid:       validIBAN,
+			replacement: "[IBAN]",
+		},
+		{
+			kind:        PIICard,
+			hint:        hasDigit,
+			pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			valid:       validLuhn,
+			replacement: "[CARD]",
+		},
+	}
+
+	s := &Scrubber{}
+	for _, d := range all {
+		if len(kinds) == 0 || containsKind(kinds, d.kind) {
+			s.detectors = append(s.detectors, d)
+		}
+	}
+	return s
+}
+
+func containsKind(kinds []PIIKind, kind PIIKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub returns value with the personal data replaced. A nil Scrubber
+// returns value unchanged.
+func (s *Scrubber) Scrub(value string) string {
+	if s == nil {
+		return value
+	}
+	for _, d := range s.detectors {
+		if !d.hint(value) {
+			continue
+		}
+		value = d.pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if d.valid != nil && !d.valid(match) {
+				return match
+			}
+			atomic.AddUint64(&d.count, 1)
+			return d.pattern.ReplaceAllString(match, d.replacement)
+		})
+	}
+	return value
+}
+
+// Counts returns the number of replacements made so far, by kind. A nil
+// Scrubber returns nil.
+func (s *Scrubber) Counts() map[PIIKind]uint64 {
+	if s == nil {
+		return nil
+	}
+	counts := make(map[PIIKind]uint64, len(s.detectors))
+	for _, d := range s.detectors {
+		counts[d.kind] = atomic.LoadUint64(&d.count)
+	}
+	return counts
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexAny(s, "0123456789") >= 0
+}
+
+// validLuhn reports whether the digits of s pass the Luhn checksum.
+func validLuhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validIBAN reports whether s passes the ISO 13616 mod-97 checksum.
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+
+	// Letters count as two digits, A = 10 to Z = 35
+	rem := 0
+	for _, c := range s[4:] + s[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			rem = (rem*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			rem = (rem*100 + int(c-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return rem == 1
+}
@@ -0,0 +1,26 @@
+package zerologger
+
+import "github.com/rs/zerolog"
+
+// SlowFieldName is the field name that marks the logs of slow requests.
+var SlowFieldName = "slow"
+
+// slow reports whether the request took at least the slow threshold of its
+// route.
+func (cfg *Config) slow(lc *logContext) bool {
+	threshold := cfg.SlowThreshold
+	if len(cfg.SlowRoutes) > 0 {
+		if t, ok := cfg.SlowRoutes[lc.c.Route()]; ok {
+			threshold = t
+		}
+	}
+	return threshold > 0 && lc.latency >= threshold
+}
+
+// escalate raises the level of a slow request to at least Warn.
+func escalate(level zerolog.Level) zerolog.Level {
+	if level < zerolog.WarnLevel {
+		return zerolog.WarnLevel
+	}
+	return level
+}
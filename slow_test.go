@@ -0,0 +1,91 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+func Test_SlowThreshold(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:        []string{TagRoute},
+		SlowThreshold: time.Second,
+		SlowRoutes: map[string]time.Duration{
+			"/export/:id": time.Minute,
+			"/stream":     0,
+		},
+		SlowFormat: []string{"header:X-Tenant>tenant", TagGoroutines},
+		Clock:      clock,
+		Output:     buf,
+	}))
+
+	handler := func(c echo.Context) error {
+		clock.Add(2 * time.Second)
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/users/:id", handler)
+	e.GET("/export/:id", handler)
+	e.GET("/stream", handler)
+	e.GET("/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/export/1", "/stream", "/fast"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Tenant", "acme")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Regexp(t, `^\{"level":"warn","route":"/users/:id","tenant":"acme","goroutines":\d+,"slow":true,`, lines[0])
+	require.Regexp(t, `^\{"level":"info","route":"/export/:id","time"`, lines[1])
+	require.Regexp(t, `^\{"level":"info","route":"/stream","time"`, lines[2])
+	require.Regexp(t, `^\{"level":"info","route":"/fast","time"`, lines[3])
+}
+
+func Test_SlowFormat_Duplicates(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:        []string{TagRoute, TagLatency},
+		SlowThreshold: time.Second,
+		SlowFormat:    []string{TagLatency, "header:X-Tenant>tenant", "header:X-Tenant>tenant"},
+		Clock:         clock,
+		Output:        buf,
+	}))
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		clock.Add(2 * time.Second)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Tenant", "acme")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, 1, strings.Count(buf.String(), `"latency"`))
+	require.Equal(t, 1, strings.Count(buf.String(), `"tenant"`))
+	require.Contains(t, buf.String(), `"route":"/users/:id","latency":`)
+}
+
+func Test_SlowThreshold_Validate(t *testing.T) {
+	require.Error(t, Config{SlowThreshold: -time.Second}.Validate())
+	require.Error(t, Config{SlowRoutes: map[string]time.Duration{"/": -time.Second}}.Validate())
+	require.Error(t, Config{SlowFormat: []string{"latncy"}}.Validate())
+}
@@ -0,0 +1,195 @@
+package zerologger
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// W3C Trace Context headers, see https://www.w3.org/TR/trace-context/
+const (
+	HeaderTraceparent = "Traceparent"
+	HeaderTracestate  = "Tracestate"
+)
+
+// traceKey is the request store key of the trace context.
+const traceKey = "zerologger.trace"
+
+// traceContextKey is the context.Context key of the trace context.
+type traceContextKey struct{}
+
+// TraceContext is the W3C Trace Context of a request.
+type TraceContext struct {
+	// TraceID is the 32 hex digit ID of the whole trace.
+	TraceID string
+
+	// SpanID is the 16 hex digit ID of the current span. This is the span
+	// generated for this hop with Config.GenerateSpanID, or the parent-id
+	// of the traceparent header otherwise.
+	SpanID string
+
+	// ParentSpanID is the parent-id of the traceparent header when a span
+	// was generated for this hop.
+	ParentSpanID string
+
+	// Flags are the trace flags, bit 0 is the sampled flag.
+	Flags byte
+
+	// State is the vendor specific tracestate header, as received.
+	State string
+}
+
+// Valid reports whether the trace context has a trace ID and a span ID.
+func (tc TraceContext) Valid() bool {
+	return tc.TraceID != "" && tc.SpanID != ""
+}
+
+// Sampled reports whether the sampled flag is set.
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 != 0
+}
+
+// Traceparent returns the traceparent header value for the span.
+func (tc TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceID, tc.SpanID, tc.Flags)
+}
+
+// Trace returns the trace context of the current request. Outside of the
+// middleware, or when it does not use tracing, it is parsed from the
+// request headers.
+func Trace(ctx echo.Context) TraceContext {
+	return traceOf(&echoContext{ctx: ctx})
+}
+
+// traceOf returns the trace context of a request, like Trace.
+func traceOf(c Context) TraceContext {
+	if tc, ok := c.Get(traceKey).(TraceContext); ok {
+		return tc
+	}
+	return ParseTraceContext(c.Header(HeaderTraceparent), c.Header(HeaderTracestate))
+}
+
+// TraceFromContext returns the trace context stored in a request context by
+// the middleware.
+func TraceFromContext(ctx context.Context) TraceContext {
+	tc, _ := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc
+}
+
+// ParseTraceContext parses the traceparent and tracestate headers. It
+// returns an empty TraceContext if traceparent is not valid.
+func ParseTraceContext(traceparent, tracestate string) TraceContext {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(traceparent) < 55 || traceparent[2] != '-' || traceparent[35] != '-' || traceparent[52] != '-' {
+		return TraceContext{}
+	}
+
+	version := traceparent[0:2]
+	traceID := traceparent[3:35]
+	spanID := traceparent[36:52]
+	flags := traceparent[53:55]
+
+	if !isHex(version) || version == "ff" || !isHex(traceID) || !isHex(spanID) || !isHex(flags) {
+		return TraceContext{}
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return TraceContext{}
+	}
+	// Version 00 has exactly 4 fields, later versions may append more
+	if len(traceparent) > 55 && (version == "00" || traceparent[55] != '-') {
+		return TraceContext{}
+	}
+
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+
+	return TraceContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Flags:   f[0],
+		State:   tracestate,
+	}
+}
+
+// isHex reports whether s only holds lowercase hex digits.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHex returns n random bytes as hex digits.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	randomBytes(b)
+	return hex.EncodeToString(b)
+}
+
+// setTrace parses the trace context of the request, generates a span for
+// this hop if configured, and stores the result in both contexts.
+func setTrace(c Adapter, cfg *Config) {
+	tc := ParseTraceContext(c.Header(HeaderTraceparent), c.Header(HeaderTracestate))
+
+	if cfg.GenerateSpanID {
+		if tc.Valid() {
+			tc.ParentSpanID = tc.SpanID
+		} else {
+			tc = TraceContext{TraceID: randomHex(16)}
+		}
+		tc.SpanID = randomHex(8)
+		c.SetResponseHeader(HeaderTraceparent, tc.Traceparent())
+	}
+
+	c.Set(traceKey, tc)
+	c.SetContext(context.WithValue(c.Context(), traceContextKey{}, tc))
+}
+
+// keepTrace stores a valid trace context of the request in the request
+// context, for Transport, when the middleware does not trace requests
+// itself.
+func keepTrace(c Adapter) {
+	traceparent := c.Header(HeaderTraceparent)
+	if traceparent == "" {
+		return
+	}
+	if tc := ParseTraceContext(traceparent, c.Header(HeaderTracestate)); tc.Valid() {
+		c.SetContext(context.WithValue(c.Context(), traceContextKey{}, tc))
+	}
+}
+
+// isTraceTag reports whether tag needs the trace context.
+func isTraceTag(tag string) bool {
+	switch tag {
+	case TagTraceID, TagSpanID, TagParentSpanID, TagTraceFlags, TagTraceState:
+		return true
+	}
+	return false
+}
+
+// traceValue returns the function that reads the value of a trace tag.
+func traceValue(tag string) func(tc TraceContext) string {
+	switch tag {
+	case TagTraceID:
+		return func(tc TraceContext) string { return tc.TraceID }
+	case TagSpanID:
+		return func(tc TraceContext) string { return tc.SpanID }
+	case TagParentSpanID:
+		return func(tc TraceContext) string { return tc.ParentSpanID }
+	case TagTraceFlags:
+		return func(tc TraceContext) string {
+			if !tc.Valid() {
+				return ""
+			}
+			return fmt.Sprintf("%02x", tc.Flags)
+		}
+	default:
+		return func(tc TraceContext) string { return tc.State }
+	}
+}
@@ -0,0 +1,108 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+)
+
+const (
+	traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tracestate  = "congo=t61rcWkgMzE"
+)
+
+func Test_ParseTraceContext(t *testing.T) {
+	tc := ParseTraceContext(traceparent, tracestate)
+	require.Equal(t, TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Flags:   0x01,
+		State:   tracestate,
+	}, tc)
+	require.True(t, tc.Valid())
+	require.True(t, tc.Sampled())
+	require.Equal(t, traceparent, tc.Traceparent())
+
+	// Future versions may add fields
+	require.True(t, ParseTraceContext("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", "").Valid())
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	for _, v := range invalid {
+		require.False(t, ParseTraceContext(v, "").Valid(), v)
+	}
+}
+
+func Test_TagTrace(t *testing.T) {
+	buf, e := testEcho(TagTraceID, TagSpanID, TagTraceFlags, TagTraceState)
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	req.Header.Set(HeaderTraceparent, traceparent)
+	req.Header.Set(HeaderTracestate, tracestate)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Contains(t, buf.String(), `"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","traceFlags":"01","traceState":"congo=t61rcWkgMzE"`)
+
+	// No trace, no fields
+	buf.Reset()
+	req = httptest.NewRequest(http.MethodGet, echoURI, nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotContains(t, buf.String(), "trace")
+}
+
+func Test_GenerateSpanID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:              []string{TagTraceID, TagSpanID, TagParentSpanID},
+		ContextFormat:       []string{TagTraceID, TagSpanID},
+		EnableContextLogger: true,
+		Output:              buf,
+		GenerateSpanID:      true,
+	}))
+
+	var tc TraceContext
+	e.GET("/", func(c echo.Context) error {
+		tc = Trace(c)
+		require.Equal(t, tc, TraceFromContext(c.Request().Context()))
+		FromContext(c).Info().Msg("handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, traceparent)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	require.Equal(t, "00f067aa0ba902b7", tc.ParentSpanID)
+	require.Len(t, tc.SpanID, 16)
+	require.NotEqual(t, tc.ParentSpanID, tc.SpanID)
+	require.Equal(t, tc.Traceparent(), res.Header().Get(HeaderTraceparent))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"traceId":"`+tc.TraceID+`","spanId":"`+tc.SpanID+`"`)
+	require.Contains(t, lines[1], `"parentSpanId":"00f067aa0ba902b7"`)
+
+	// Start a new trace
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	require.Len(t, tc.TraceID, 32)
+	require.Empty(t, tc.ParentSpanID)
+}
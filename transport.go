@@ -0,0 +1,308 @@
+package zerologger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Transport returns an http.RoundTripper that logs the outbound requests of
+// base, or of http.DefaultTransport if base is nil.
+//
+// The tags of Format mean the same as for New, from the side of the client:
+// TagStatus and TagProtocol describe the response, TagBytesSent is the
+// length of the request body and TagBytesReceived the Content-Length of the
+// response. Tags of the server side, such as TagIP, TagRoute or TagBody, are
+// ignored. Without a Format, it logs the method, url, status, latency, bytes
+// and error of the request.
+//
+// The request ID and trace context of the incoming request are propagated
+// in the headers of the outbound request, when its context comes from the
+// middleware. Once a Transport has been created, the middleware keeps the
+// ones received from clients in the request context too. It is then logged with the per-request logger, so it carries
+// the fields of Config.ContextFormat, and with the logger of the config
+// otherwise. Transport errors are logged at Error, responses at the level
+// of Config.LevelFunc.
+func Transport(base http.RoundTripper, config ...Config) http.RoundTripper {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Format == nil {
+		cfg.Format = []string{TagMethod, TagURL, TagStatus, TagLatency, TagBytesSent, TagBytesReceived, TagError}
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	atomic.AddInt32(&transports, 1)
+
+	t := &transport{base: base, cfg: setConfig(cfg)}
+	t.cfg.timeZoneLocation = loadLocation(t.cfg.TimeZone)
+	t.writers = compileTransportFormat(&t.cfg)
+	return t
+}
+
+// transports counts the Transports created. Until there is one, the
+// middleware does not store the request ID and trace context of incoming
+// requests in their context, as nothing would propagate them.
+var transports int32
+
+// propagating reports whether a Transport may propagate the request ID and
+// trace context of the request context.
+func propagating() bool {
+	return atomic.LoadInt32(&transports) > 0
+}
+
+// transport is the logging http.RoundTripper.
+type transport struct {
+	base    http.RoundTripper
+	cfg     Config
+	writers []fieldWriter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := &t.cfg
+	req = propagate(req, cfg)
+
+	lc := logContextPool.Get().(*logContext)
+	lc.client = clientContext{req: req}
+	lc.c = &lc.client
+
+	// Sample the log before the request, like the middleware
+	rate, keep := 1, true
+	if cfg.Sampler != nil {
+		rate, keep = cfg.Sampler.Sample(lc.c)
+	}
+
+	start := cfg.Clock.Now()
+	resp, err := t.base.RoundTrip(req)
+	stop := cfg.Clock.Now()
+
+	lc.client.resp = resp
+	lc.err, lc.latency = err, stop.Sub(start)
+
+	// Log dropped requests anyway if they failed, like the middleware
+	if !keep && failed(lc) {
+		rate, keep = 0, true
+	}
+
+	if keep {
+		status := lc.c.Status()
+		level := zerolog.ErrorLevel
+		if err == nil {
+			level = cfg.LevelFunc(lc.c, status, nil)
+		}
+
+		// Log with the per-request logger of the middleware if there is one
+		logger := zerolog.Ctx(req.Context())
+		if logger.GetLevel() == zerolog.Disabled {
+			logger = &cfg.logger
+		}
+
+		event := logger.WithLevel(level)
+		for _, write := range t.writers {
+			event = write(lc, event)
+		}
+		if cfg.Sampler != nil {
+			event = event.Int(SampleRateFieldName, rate)
+		}
+		event.Msg(http.StatusText(status))
+	}
+
+	*lc = logContext{}
+	logContextPool.Put(lc)
+
+	return resp, err
+}
+
+// propagate sets the request ID and trace context headers of the incoming
+// request on the outbound request, unless they are set already. The
+// request is cloned before its headers are changed.
+func propagate(req *http.Request, cfg *Config) *http.Request {
+	ctx := req.Context()
+	id := RequestIDFromContext(ctx)
+	tc := TraceFromContext(ctx)
+
+	setID := id != "" && req.Header.Get(cfg.RequestIDHeader) == ""
+	setTrace := tc.Valid() && req.Header.Get(HeaderTraceparent) == ""
+	if !setID && !setTrace {
+		return req
+	}
+
+	req = req.Clone(ctx)
+	if setID {
+		req.Header.Set(cfg.RequestIDHeader, id)
+	}
+	if setTrace {
+		req.Header.Set(HeaderTraceparent, tc.Traceparent())
+		if tc.State != "" {
+			req.Header.Set(HeaderTracestate, tc.State)
+		}
+	}
+	return req
+}
+
+// compileTransportFormat parses cfg.Format into field writers for outbound
+// requests.
+func compileTransportFormat(cfg *Config) []fieldWriter {
+	fields := make([]compiledField, 0, len(cfg.Format))
+	for _, entry := range cfg.Format {
+		tag, spec := cfg.field(entry)
+		if w := compileTransportTag(cfg, tag, spec); w != nil {
+			fields = append(fields, compiledField{path: spec.path, write: w})
+		}
+	}
+	return groupFields(cfg, fields)
+}
+
+// compileTransportTag returns the field writer of a tag for outbound
+// requests, or nil if the tag only makes sense on the server side.
+func compileTransportTag(cfg *Config, tag string, spec fieldSpec) fieldWriter {
+	key := spec.key()
+
+	switch tag {
+	case TagIP, TagIPs, TagRoute, TagBody, TagResBody:
+		return nil
+	case TagTime:
+		// Formatted per request, since there is no Close to stop a ticker
+		return func(_ *logContext, event *zerolog.Event) *zerolog.Event {
+			return event.Str(key, cfg.Clock.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
+		}
+	case TagProtocol:
+		if spec.format == formatVersion {
+			return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+				if lc.client.resp == nil {
+					return event
+				}
+				return event.Str(key, strings.TrimPrefix(lc.client.resp.Proto, "HTTP/"))
+			}
+		}
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.client.resp == nil {
+				return event
+			}
+			return event.Str(key, lc.client.resp.Proto)
+		}
+	case TagBytesSent:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.client.req.ContentLength < 0 {
+				return event
+			}
+			return event.Int64(key, lc.client.req.ContentLength)
+		}
+	case TagBytesReceived:
+		return func(lc *logContext, event *zerolog.Event) *zerolog.Event {
+			if lc.client.resp == nil || lc.client.resp.ContentLength < 0 {
+				return event
+			}
+			return event.Int64(key, lc.client.resp.ContentLength)
+		}
+	}
+	return compileTag(cfg, tag, spec, nil)
+}
+
+// clientContext is the Context of an outbound request. The response is nil
+// when the request failed.
+type clientContext struct {
+	req  *http.Request
+	resp *http.Response
+}
+
+func (c *clientContext) Method() string {
+	return c.req.Method
+}
+
+func (c *clientContext) Scheme() string {
+	return c.req.URL.Scheme
+}
+
+func (c *clientContext) Host() string {
+	if c.req.Host != "" {
+		return c.req.Host
+	}
+	return c.req.URL.Host
+}
+
+func (c *clientContext) Path() string {
+	return c.req.URL.Path
+}
+
+func (c *clientContext) URL() string {
+	return c.req.URL.String()
+}
+
+func (c *clientContext) RequestURI() string {
+	return c.req.URL.RequestURI()
+}
+
+func (c *clientContext) Proto() string {
+	return c.req.Proto
+}
+
+func (c *clientContext) Header(name string) string {
+	return headerValue(c.req.Header, name)
+}
+
+func (c *clientContext) RealIP() string {
+	return ""
+}
+
+func (c *clientContext) Route() string {
+	return ""
+}
+
+func (c *clientContext) Param(string) string {
+	return ""
+}
+
+func (c *clientContext) QueryString() string {
+	return c.req.URL.RawQuery
+}
+
+func (c *clientContext) QueryParam(name string) string {
+	return queryValue(c.req.URL.RawQuery, name)
+}
+
+func (c *clientContext) FormValue(string) string {
+	return ""
+}
+
+func (c *clientContext) Cookie(name string) string {
+	if cookie, err := c.req.Cookie(name); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (c *clientContext) Get(string) interface{} {
+	return nil
+}
+
+func (c *clientContext) Context() context.Context {
+	return c.req.Context()
+}
+
+func (c *clientContext) Status() int {
+	if c.resp == nil {
+		return 0
+	}
+	return c.resp.StatusCode
+}
+
+func (c *clientContext) Size() int64 {
+	if c.resp == nil || c.resp.ContentLength < 0 {
+		return 0
+	}
+	return c.resp.ContentLength
+}
+
+func (c *clientContext) ResponseHeader(name string) string {
+	if c.resp == nil {
+		return ""
+	}
+	return c.resp.Header.Get(name)
+}
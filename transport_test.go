@@ -0,0 +1,176 @@
+package zerologger_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_Transport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	defer srv.Close()
+
+	buf := new(bytes.Buffer)
+	client := &http.Client{Transport: Transport(nil, Config{Output: buf})}
+
+	res, err := client.Post(srv.URL+"/items?x=1", echo.MIMETextPlain, strings.NewReader("abc"))
+	require.NoError(t, err)
+	res.Body.Close()
+
+	require.Contains(t, buf.String(), `"level":"debug","method":"POST","url":"`+srv.URL+`/items?x=1","status":201,"latency":`)
+	require.Contains(t, buf.String(), `"bytesSent":3,"bytesReceived":7,`)
+	require.Contains(t, buf.String(), `"message":"Created"`)
+}
+
+func Test_Transport_Error(t *testing.T) {
+	buf := new(bytes.Buffer)
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	client := &http.Client{Transport: Transport(base, Config{
+		Format: []string{TagMethod, TagStatus, TagError},
+		Output: buf,
+	})}
+
+	_, err := client.Get("http://example.com")
+	require.Error(t, err)
+	require.Contains(t, buf.String(), `{"level":"error","method":"GET","status":0,"error":"connection refused"`)
+}
+
+func Test_Transport_Propagate(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	buf := new(bytes.Buffer)
+	client := &http.Client{Transport: Transport(nil, Config{Format: []string{TagID, TagTraceID, TagStatus}})}
+
+	e := echo.New()
+	e.Use(New(Config{
+		Format:              []string{TagStatus},
+		ContextFormat:       []string{TagRoute},
+		EnableContextLogger: true,
+		RequestIDGenerator:  func() string { return "generated" },
+		GenerateSpanID:      true,
+		Output:              buf,
+	}))
+	e.GET("/call", func(c echo.Context) error {
+		req, err := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, srv.URL, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		// The request of the handler is not changed
+		require.Empty(t, req.Header.Get(echo.HeaderXRequestID))
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve(e, "/call")
+
+	tc := ParseTraceContext(header.Get(HeaderTraceparent), "")
+	require.True(t, tc.Valid())
+	require.Equal(t, "generated", header.Get(echo.HeaderXRequestID))
+
+	// The outbound request is logged with the fields of the handler logger
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `{"level":"info","route":"/call","id":"generated","traceId":"`+tc.TraceID+`","status":200`)
+	require.Contains(t, lines[1], `{"level":"info","status":200`)
+}
+
+func Test_Transport_Propagate_Default(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil, Config{Output: new(bytes.Buffer)})}
+
+	e := echo.New()
+	e.Use(New(Config{Output: new(bytes.Buffer)}))
+	e.GET("/call", func(c echo.Context) error {
+		req, err := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, srv.URL, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return c.NoContent(http.StatusOK)
+	})
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/call", nil)
+	req.Header.Set(echo.HeaderXRequestID, "incoming")
+	req.Header.Set(HeaderTraceparent, traceparent)
+	req.Header.Set(HeaderTracestate, "vendor=1")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	require.Equal(t, "incoming", header.Get(echo.HeaderXRequestID))
+	require.Equal(t, traceparent, header.Get(HeaderTraceparent))
+	require.Equal(t, "vendor=1", header.Get(HeaderTracestate))
+}
+
+func Test_Transport_NoGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		Transport(nil, Config{Format: []string{TagTime, TagStatus}})
+	}
+	require.Equal(t, before, runtime.NumGoroutine())
+}
+
+func Test_Transport_Time(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Date(2021, time.November, 27, 22, 48, 39, 0, time.UTC))
+	buf := new(bytes.Buffer)
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := &http.Client{Transport: Transport(base, Config{
+		Format:     []string{TagTime},
+		TimeFormat: time.RFC3339,
+		TimeZone:   "UTC",
+		Clock:      clock,
+		Output:     buf,
+	})}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get("http://example.com")
+		require.NoError(t, err)
+		res.Body.Close()
+		clock.Add(time.Hour)
+	}
+	require.Contains(t, buf.String(), `"time":"2021-11-27T22:48:39Z"`)
+	require.Contains(t, buf.String(), `"time":"2021-11-27T23:48:39Z"`)
+}
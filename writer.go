@@ -0,0 +1,203 @@
+package zerologger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// writerHooks is a response writer that wraps another one and implements
+// every optional interface of http.ResponseWriter by forwarding to it.
+// It is only used through wrapWriter, so a method is never called unless
+// the wrapped writer implements it.
+type writerHooks interface {
+	unwrapWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	io.ReaderFrom
+}
+
+// unwrapWriter is a response writer that gives access to the one it wraps,
+// as used by http.ResponseController.
+type unwrapWriter interface {
+	http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// wrapWriter returns hooks as an http.ResponseWriter that implements the
+// same optional interfaces as w, and no others. Handlers that check for
+// http.Hijacker or http.Flusher then see what the server really supports.
+func wrapWriter(w http.ResponseWriter, hooks writerHooks) http.ResponseWriter {
+	var i int
+	if _, ok := w.(http.Flusher); ok {
+		i |= 1
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		i |= 2
+	}
+	if _, ok := w.(http.Pusher); ok {
+		i |= 4
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		i |= 8
+	}
+
+	switch i {
+	case 0:
+		return struct {
+			unwrapWriter
+		}{hooks}
+	case 1:
+		return struct {
+			unwrapWriter
+			http.Flusher
+		}{hooks, hooks}
+	case 2:
+		return struct {
+			unwrapWriter
+			http.Hijacker
+		}{hooks, hooks}
+	case 3:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Hijacker
+		}{hooks, hooks, hooks}
+	case 4:
+		return struct {
+			unwrapWriter
+			http.Pusher
+		}{hooks, hooks}
+	case 5:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Pusher
+		}{hooks, hooks, hooks}
+	case 6:
+		return struct {
+			unwrapWriter
+			http.Hijacker
+			http.Pusher
+		}{hooks, hooks, hooks}
+	case 7:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{hooks, hooks, hooks, hooks}
+	case 8:
+		return struct {
+			unwrapWriter
+			io.ReaderFrom
+		}{hooks, hooks}
+	case 9:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			io.ReaderFrom
+		}{hooks, hooks, hooks}
+	case 10:
+		return struct {
+			unwrapWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{hooks, hooks, hooks}
+	case 11:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{hooks, hooks, hooks, hooks}
+	case 12:
+		return struct {
+			unwrapWriter
+			http.Pusher
+			io.ReaderFrom
+		}{hooks, hooks, hooks}
+	case 13:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{hooks, hooks, hooks, hooks}
+	case 14:
+		return struct {
+			unwrapWriter
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{hooks, hooks, hooks, hooks}
+	default:
+		return struct {
+			unwrapWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{hooks, hooks, hooks, hooks, hooks}
+	}
+}
+
+// responseWriter records the status and size of a response for NewHTTP,
+// like echo.Response does for Echo.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// WriteHeader records the first status written.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements the http.Flusher interface.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Push implements the http.Pusher interface.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	w.size += n
+	return n, err
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
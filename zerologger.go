@@ -5,8 +5,7 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,179 +19,289 @@ import (
 // The default Logger middleware from Echo uses buffers and templates and
 // writes directly to os.Stderr. This strips out all of that and sends the
 // log directly to Zerolog.
+//
+// With TagTime the timestamp is updated by a goroutine that runs for the
+// lifetime of the process. Use NewMiddleware if it needs to be stopped.
 func New(config ...Config) echo.MiddlewareFunc {
-	// Set default config
-	cfg := setConfig(config...)
+	return newMiddleware(setConfig(config...)).Handler()
+}
 
-	// Get timezone location
-	tz, err := time.LoadLocation(cfg.TimeZone)
-	if err != nil || tz == nil {
-		cfg.timeZoneLocation = time.Local
-	} else {
-		cfg.timeZoneLocation = tz
+// Middleware is a zerolog middleware for Echo that owns the goroutine
+// updating the timestamp of TagTime, so it can be stopped on shutdown.
+type Middleware struct {
+	cfg            Config
+	writers        []fieldWriter
+	slowWriters    []fieldWriter
+	contextWriters []contextWriter
+	timestamp      atomic.Value
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewMiddleware creates a new zerolog middleware for Echo. Like NewE, it
+// returns an error for an invalid config. Call Close once the server has
+// shut down to stop the timestamp goroutine.
+func NewMiddleware(config ...Config) (*Middleware, error) {
+	if len(config) > 0 {
+		if err := config[0].Validate(); err != nil {
+			return nil, err
+		}
 	}
+	return newMiddleware(setConfig(config...)), nil
+}
+
+// NewLenientMiddleware is like NewMiddleware, but ignores problems in the
+// config like New does. It is meant for the adapters of other frameworks.
+func NewLenientMiddleware(config ...Config) *Middleware {
+	return newMiddleware(setConfig(config...))
+}
 
-	// Check if format contains latency
-	for _, tag := range cfg.Format {
-		if tag == TagLatency {
+// newMiddleware prepares a middleware from a config with defaults applied.
+func newMiddleware(cfg Config) *Middleware {
+	m := &Middleware{done: make(chan struct{})}
+
+	// Get timezone location
+	cfg.timeZoneLocation = loadLocation(cfg.TimeZone)
+
+	// Slow requests are logged with the tags of both formats
+	slowFormat := mergeFormat(cfg.Format, cfg.SlowFormat)
+
+	// Check if format contains latency, body or trace tags
+	cfg.enableTrace = cfg.GenerateSpanID
+	cfg.enableLatency = cfg.SlowThreshold > 0 || len(cfg.SlowRoutes) > 0
+	for _, entry := range cfg.ContextFormat {
+		if tag, _ := splitAlias(entry); isTraceTag(tag) && cfg.EnableContextLogger {
+			cfg.enableTrace = true
+		}
+	}
+	for _, entry := range slowFormat {
+		tag, _ := splitAlias(entry)
+		if isTraceTag(tag) {
+			cfg.enableTrace = true
+		}
+		switch tag {
+		case TagLatency:
 			cfg.enableLatency = true
-			break
+		case TagBody:
+			cfg.enableBody = true
+		case TagResBody:
+			cfg.enableResBody = true
 		}
 	}
 
 	// Create correct timeformat
-	var timestamp atomic.Value
-	timestamp.Store(time.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
+	m.timestamp.Store(cfg.Clock.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
 
 	// Update date/time in a separate go routine
-	for _, tag := range cfg.Format {
-		if tag == TagTime {
-			go func() {
-				for {
-					time.Sleep(cfg.TimeInterval)
-					timestamp.Store(time.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
-				}
-			}()
-			break
+	if containsTag(slowFormat, TagTime) {
+		go m.tick(cfg.Clock.NewTicker(cfg.TimeInterval), cfg.timeZoneLocation, cfg.TimeFormat)
+	}
+
+	// Parse the format once
+	m.cfg = cfg
+	m.writers = compileFormat(&m.cfg, m.cfg.Format, &m.timestamp)
+	m.slowWriters = m.writers
+	if len(cfg.SlowFormat) > 0 {
+		m.slowWriters = compileFormat(&m.cfg, slowFormat, &m.timestamp)
+	}
+	if cfg.EnableContextLogger {
+		m.contextWriters = compileContextFormat(&m.cfg)
+	} else {
+		atomic.StoreInt32(&contextLoggerOff, 1)
+	}
+
+	return m
+}
+
+// loadLocation returns the location of a time zone name, or the local time
+// zone if the name is unknown.
+func loadLocation(name string) *time.Location {
+	tz, err := time.LoadLocation(name)
+	if err != nil || tz == nil {
+		return time.Local
+	}
+	return tz
+}
+
+// tick updates the timestamp until the middleware is closed.
+func (m *Middleware) tick(ticker Ticker, loc *time.Location, format string) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C():
+			m.timestamp.Store(now.In(loc).Format(format))
+		case <-m.done:
+			return
 		}
 	}
+}
 
-	// Set PID once
-	pid := strconv.Itoa(os.Getpid())
+// Close stops the timestamp goroutine. The middleware keeps logging after
+// Close, but the value of TagTime is no longer updated. It is safe to call
+// Close more than once.
+func (m *Middleware) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+	return nil
+}
 
+// Handler returns the Echo middleware function.
+func (m *Middleware) Handler() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			// Don't execute the middleware if Next returns true
-			if cfg.Skipper(ctx) {
+			if m.cfg.Skipper(ctx) {
 				return next(ctx)
 			}
 
-			var start, stop time.Time
+			lc := logContextPool.Get().(*logContext)
+			lc.echo = echoContext{ctx: ctx, next: next}
+			m.serve(lc, &lc.echo)
 
-			// Set latency start time
-			if cfg.enableLatency {
-				start = time.Now()
-			}
+			// End chain
+			return nil
+		}
+	}
+}
 
-			// Handle request, store err for logging
-			chainErr := next(ctx)
-			if chainErr != nil {
-				ctx.Error(chainErr)
-			}
+// Serve handles and logs a request of another framework, such as Gin or
+// Fiber. It calls the handler with Adapter.Next, and responds to the error
+// it returns, or to a recovered panic, with Adapter.Error.
+func (m *Middleware) Serve(c Adapter) {
+	m.serve(logContextPool.Get().(*logContext), c)
+}
 
-			// Set latency stop time
-			if cfg.enableLatency {
-				stop = time.Now()
-			}
+// serve handles and logs the request of c, with lc from logContextPool.
+func (m *Middleware) serve(lc *logContext, c Adapter) {
+	cfg := &m.cfg
+	lc.c = c
 
-			req := ctx.Request()
-			res := ctx.Response()
-
-			status := res.Status
-
-			var event *zerolog.Event
-			switch {
-			case status == http.StatusOK:
-				event = cfg.logger.Info()
-			case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
-				event = cfg.logger.Warn()
-			case status >= http.StatusInternalServerError:
-				event = cfg.logger.Error()
-			default:
-				event = cfg.logger.Debug()
-			}
+	var start, stop time.Time
 
-			for _, tag := range cfg.Format {
-				switch tag {
-				case TagTime:
-					event = event.Str(TagTime, timestamp.Load().(string))
-				case TagReferer:
-					event = event.Str(TagReferer, req.Referer())
-				case TagProtocol:
-					event = event.Str(TagProtocol, req.Proto)
-				case TagPid:
-					event = event.Str(TagPid, pid)
-				case TagID:
-					event = event.Str(TagID, req.Header.Get(echo.HeaderXRequestID))
-				case TagIP:
-					event = event.Str(TagIP, ctx.RealIP())
-				case TagIPs:
-					event = event.Str(TagIPs, req.Header.Get(echo.HeaderXForwardedFor))
-				case TagHost:
-					event = event.Str(TagHost, req.Host)
-				case TagPath:
-					event = event.Str(TagPath, req.URL.Path)
-				case TagURL:
-					event = event.Str(TagURL, req.URL.String())
-				case TagUA:
-					event = event.Str(TagUA, req.UserAgent())
-				case TagLatency:
-					if cfg.PrettyLatency {
-						event = event.Str(TagLatency, stop.Sub(start).String())
-					} else {
-						event = event.Dur(TagLatency, stop.Sub(start))
-					}
-				case TagBody:
-					// NOOP - Echo doesn't support it
-				case TagBytesReceived:
-					cl := req.Header.Get(echo.HeaderContentLength)
-					if cl == "" {
-						event = event.Int(TagBytesReceived, 0)
-						continue
-					}
-					i, _ := strconv.ParseInt(cl, 10, 64)
-					event = event.Int64(TagBytesReceived, i)
-				case TagBytesSent:
-					event = event.Int64(TagBytesSent, res.Size)
-				case TagRoute:
-					event = event.Str(TagRoute, ctx.Path())
-				case TagStatus:
-					event = event.Int(TagStatus, status)
-				case TagResBody:
-					// NOOP - Echo doesn't support it
-				case TagQueryStringParams:
-					event = event.Str(TagQueryStringParams, req.URL.RawQuery)
-				case TagMethod:
-					event = event.Str(TagMethod, req.Method)
-				case TagError:
-					if chainErr != nil {
-						event = event.Err(chainErr)
-					}
-				default:
-					// Check if we have a value tag i.e.: "header:x-key"
-					switch {
-					case strings.HasPrefix(tag, TagHeader):
-						event = event.Str(tag[7:], req.Header.Get(tag[7:]))
-					case strings.HasPrefix(tag, TagQuery):
-						event = event.Str(tag[6:], ctx.QueryParam(tag[6:]))
-					case strings.HasPrefix(tag, TagForm):
-						event = event.Str(tag[5:], ctx.FormValue(tag[5:]))
-					case strings.HasPrefix(tag, TagCookie):
-						cookie, err := ctx.Cookie(tag[7:])
-						if err == nil {
-							event = event.Str(tag[7:], cookie.Value)
-						}
-					case strings.HasPrefix(tag, TagLocals):
-						switch v := ctx.Get(tag[7:]).(type) {
-						case []byte:
-							event = event.Bytes(tag[7:], v)
-						case string:
-							event = event.Str(tag[7:], v)
-						case nil:
-							// NOOP
-						default:
-							event = event.Str(tag[7:], fmt.Sprintf("%v", v))
-						}
-					}
-				}
-			}
+	// Set latency start time
+	if cfg.enableLatency {
+		start = cfg.Clock.Now()
+	}
 
-			event.Msg(http.StatusText(status))
+	// Make sure the request has an ID, or keep the one it came with
+	setRequestID(c, cfg)
 
-			// End chain
-			return nil
+	// Parse the trace context before the logger needs it, or keep the one
+	// the request came with for Transport
+	if cfg.enableTrace {
+		setTrace(c, cfg)
+	} else if propagating() {
+		keepTrace(c)
+	}
+
+	// Give handlers a logger with the request fields
+	if cfg.EnableContextLogger {
+		attachLogger(c, cfg.logger, m.contextWriters)
+	}
+
+	// Sample the log before the handler, so dropped requests skip the body
+	// capture
+	rate, keep := 1, true
+	if cfg.Sampler != nil {
+		rate, keep = cfg.Sampler.Sample(c)
+	}
+
+	// Mirror the request body into a bounded buffer
+	if cfg.enableBody && keep {
+		if body := c.Body(); body != nil && allowedContentType(c.Header(echo.HeaderContentType), cfg.BodyContentTypes) {
+			lc.reqBody = &bodyReader{ReadCloser: body, capture: newBodyBuffer(cfg.BodyLimit)}
+			c.SetBody(lc.reqBody)
 		}
 	}
+
+	// Mirror the response body into a bounded buffer
+	if cfg.enableResBody && keep {
+		w := c.Writer()
+		lc.resBody = &bodyWriter{ResponseWriter: w, capture: newBodyBuffer(cfg.BodyLimit), contentTypes: cfg.BodyContentTypes}
+		c.SetWriter(wrapWriter(w, lc.resBody))
+	}
+
+	// Handle request, store err for logging
+	if cfg.Recover {
+		lc.recovered, lc.err = callRecover(c.Next, cfg.RecoverStackSize)
+	} else {
+		lc.err = c.Next()
+	}
+	if lc.err != nil {
+		c.Error(lc.err)
+	}
+	if lc.resBody != nil {
+		c.SetWriter(lc.resBody.ResponseWriter)
+	}
+
+	// Capture the part of the body the handler did not read
+	if lc.reqBody != nil {
+		lc.reqBody.fill()
+	}
+
+	// Set latency stop time
+	if cfg.enableLatency {
+		stop = cfg.Clock.Now()
+		lc.latency = stop.Sub(start)
+	}
+
+	status := c.Status()
+
+	// Log dropped requests anyway if they failed or were slow. The sampler
+	// counts them in the rate of its next log already
+	slow := cfg.slow(lc)
+	if !keep && (slow || failed(lc)) {
+		rate, keep = 0, true
+	}
+
+	if keep {
+		level, w := cfg.LevelFunc(c, status, lc.err), m.writers
+		if slow {
+			level, w = escalate(level), m.slowWriters
+		}
+
+		event := cfg.logger.WithLevel(level)
+		for _, write := range w {
+			event = write(lc, event)
+		}
+		if slow {
+			event = event.Bool(SlowFieldName, true)
+		}
+		if lc.recovered != nil {
+			event = event.
+				Str(PanicFieldName, fmt.Sprint(lc.recovered.value)).
+				Str(StackFieldName, string(lc.recovered.stack))
+		}
+		if f, ok := requestFieldsOf(c); ok {
+			f.apply(event, cfg)
+		}
+		if cfg.Sampler != nil {
+			event = event.Int(SampleRateFieldName, rate)
+		}
+
+		event.Msg(http.StatusText(status))
+	}
+
+	rec := lc.recovered
+	*lc = logContext{}
+	logContextPool.Put(lc)
+
+	// Let an outer middleware or the server handle the panic too
+	if rec != nil && cfg.RePanic {
+		panic(rec.value)
+	}
+}
+
+// NewE is like New, but returns an error instead of ignoring problems in the
+// config, such as unknown tags or an invalid TimeZone. See Config.Validate.
+func NewE(config ...Config) (echo.MiddlewareFunc, error) {
+	m, err := NewMiddleware(config...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Handler(), nil
 }
 
 // Initialize is a convenience function to configure Zerolog with some useful defaults.
@@ -244,9 +353,18 @@ const (
 	TagBytesReceived     = "bytesReceived"
 	TagRoute             = "route"
 	TagError             = "error"
+	TagTraceID           = "traceId"
+	TagSpanID            = "spanId"
+	TagParentSpanID      = "parentSpanId"
+	TagTraceFlags        = "traceFlags"
+	TagTraceState        = "traceState"
+	TagGoroutines        = "goroutines"
 	TagHeader            = "header:"
-	TagLocals            = "locals:"
 	TagQuery             = "query:"
 	TagForm              = "form:"
 	TagCookie            = "cookie:"
+
+	// Deprecated: TagLocals logs values from echo.Context.Get as strings,
+	// use Set or AddFields to add typed fields to the access log instead.
+	TagLocals = "locals:"
 )
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -20,6 +21,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	. "czechia.dev/zerologger"
+	"czechia.dev/zerologger/zerologgertest"
 )
 
 func testEcho(format ...string) (*bytes.Buffer, *echo.Echo) {
@@ -64,15 +66,35 @@ func Test_TagPid(t *testing.T) {
 }
 
 func Test_TagTime(t *testing.T) {
-	buf, e := testEcho(TagTime)
-	// TODO: this format will cause false negatives
-	format := time.RFC3339
+	now := time.Date(2021, time.August, 9, 13, 14, 15, 0, time.UTC)
+	clock := zerologgertest.NewFakeClock(now)
+
+	buf := new(bytes.Buffer)
+	m, err := NewMiddleware(Config{
+		Format:   []string{TagTime},
+		Output:   buf,
+		TimeZone: "UTC",
+		Clock:    clock,
+	})
+	require.NoError(t, err)
+	defer m.Close()
+
+	e := echo.New()
+	e.Use(m.Handler())
 
 	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
 	res := httptest.NewRecorder()
 	e.ServeHTTP(res, req)
 	data, _ := io.ReadAll(buf)
-	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagTime, time.Now().Format(format)))
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagTime, "2021-08-09T13:14:15Z"))
+
+	// The ticker has received the tick, wait for the timestamp to be stored
+	clock.Add(time.Second)
+	require.Eventually(t, func() bool {
+		buf.Reset()
+		e.ServeHTTP(httptest.NewRecorder(), req)
+		return strings.Contains(buf.String(), fmt.Sprintf(`"%s":"%s"`, TagTime, "2021-08-09T13:14:16Z"))
+	}, time.Second, time.Millisecond)
 }
 
 func Test_TagReferer(t *testing.T) {
@@ -198,6 +220,30 @@ func Test_TagLatency(t *testing.T) {
 	require.Contains(t, string(data), fmt.Sprintf(`"%s":0.0`, TagLatency))
 }
 
+func Test_TagLatency_Clock(t *testing.T) {
+	clock := zerologgertest.NewFakeClock(time.Now())
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:        []string{TagLatency},
+		Output:        buf,
+		Clock:         clock,
+		PrettyLatency: true,
+	}))
+
+	e.GET("/info.html", func(c echo.Context) error {
+		clock.Add(1500 * time.Millisecond)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, echoURI, nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"1.5s"`, TagLatency))
+}
+
 func Test_TagStatus(t *testing.T) {
 	buf, e := testEcho(TagStatus)
 	status := 404
@@ -210,8 +256,6 @@ func Test_TagStatus(t *testing.T) {
 }
 
 func Test_TagResBody(t *testing.T) {
-	t.Skip("TagResBody not implemented on Echo.")
-
 	buf, e := testEcho(TagResBody)
 	body := "test"
 
@@ -226,6 +270,40 @@ func Test_TagResBody(t *testing.T) {
 	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagResBody, body))
 }
 
+func Test_TagResBody_Error(t *testing.T) {
+	buf, e := testEcho(TagResBody)
+
+	e.GET("/body", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/body", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":{"message":"invalid"}`, TagResBody))
+}
+
+func Test_TagResBody_Stream(t *testing.T) {
+	buf, e := testEcho(TagResBody)
+
+	e.GET("/body", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("test"))
+		c.Response().Flush()
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/body", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, "test", res.Body.String())
+	require.NotContains(t, string(data), fmt.Sprintf(`"%s":`, TagResBody))
+}
+
 func Test_TagQueryStringParams(t *testing.T) {
 	buf, e := testEcho(TagQueryStringParams)
 	params := "test=true"
@@ -238,8 +316,6 @@ func Test_TagQueryStringParams(t *testing.T) {
 }
 
 func Test_TagBody(t *testing.T) {
-	t.Skip("TagBody not implemented on Echo.")
-
 	buf, e := testEcho(TagBody)
 	body := "test"
 
@@ -249,12 +325,71 @@ func Test_TagBody(t *testing.T) {
 
 	r := strings.NewReader("test")
 	req := httptest.NewRequest(http.MethodPost, echoURI, r)
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
 	res := httptest.NewRecorder()
 	e.ServeHTTP(res, req)
 	data, _ := io.ReadAll(buf)
 	require.Contains(t, string(data), fmt.Sprintf(`"%s":"%s"`, TagBody, body))
 }
 
+func Test_TagBody_JSON(t *testing.T) {
+	buf, e := testEcho(TagBody)
+	body := "{\n  \"test\": true\n}\n"
+
+	e.POST("/info.html", func(c echo.Context) error {
+		data, err := io.ReadAll(c.Request().Body)
+		require.NoError(t, err)
+		require.Equal(t, body, string(data))
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":{"test":true}`, TagBody))
+}
+
+func Test_TagBody_Limit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.Use(New(Config{
+		Format:        []string{TagBody},
+		Output:        buf,
+		BodyLimit:     4,
+		BodyTruncated: "[...]",
+	}))
+	body := `{"test":true}`
+
+	e.POST("/info.html", func(c echo.Context) error {
+		data, err := io.ReadAll(c.Request().Body)
+		require.NoError(t, err)
+		require.Equal(t, body, string(data))
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Contains(t, string(data), fmt.Sprintf(`"%s":"{\"te[...]"`, TagBody))
+}
+
+func Test_TagBody_ContentType(t *testing.T) {
+	buf, e := testEcho(TagBody)
+
+	req := httptest.NewRequest(http.MethodPost, echoURI, strings.NewReader("test"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	data, _ := io.ReadAll(buf)
+	require.NotContains(t, string(data), fmt.Sprintf(`"%s":`, TagBody))
+}
+
 func Test_TagBytesSent(t *testing.T) {
 	buf, e := testEcho(TagBytesSent)
 	status := 24
@@ -637,6 +772,13 @@ func Benchmark_Zerologger(b *testing.B) {
 				TagTime, TagReferer, TagProtocol, TagID, TagIP, TagHost, TagMethod, TagPath, TagURL, TagUA, TagLatency, TagStatus, TagBytesSent, TagBytesReceived, TagError, "header:h-test", "query:q-test", "form:f-test",
 			},
 		},
+		{
+			name: "MaximumPrefix",
+			format: []string{
+				TagTime, TagReferer, TagProtocol, TagID, TagIP, TagHost, TagMethod, TagPath, TagURL, TagUA, TagLatency, TagStatus, TagBytesSent, TagBytesReceived, TagError, "header:h-test", "query:q-test", "form:f-test",
+				"header:x-forwarded-proto", "header:accept-language", "cookie:c-test", "locals:l-test",
+			},
+		},
 	}
 
 	for _, v := range runs {
@@ -657,3 +799,38 @@ func Benchmark_Zerologger(b *testing.B) {
 		})
 	}
 }
+
+func Test_Middleware_Close(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	var ms []*Middleware
+	for i := 0; i < 10; i++ {
+		m, err := NewMiddleware(Config{
+			Format: []string{TagTime, TagStatus},
+			Output: io.Discard,
+		})
+		require.NoError(t, err)
+		ms = append(ms, m)
+	}
+	require.GreaterOrEqual(t, runtime.NumGoroutine(), before+10)
+
+	e := echo.New()
+	e.Use(ms[0].Handler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotFound, res.Code)
+
+	for _, m := range ms {
+		require.NoError(t, m.Close())
+		require.NoError(t, m.Close())
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+
+	_, err := NewMiddleware(Config{Format: []string{"latncy"}})
+	require.Error(t, err)
+}
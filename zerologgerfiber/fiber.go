@@ -0,0 +1,241 @@
+// Package zerologgerfiber adapts the zerologger middleware to Fiber, so
+// Fiber services log with the same Config and tags as Echo services.
+package zerologgerfiber
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"czechia.dev/zerologger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a new zerolog middleware for Fiber. TagRoute is the route of
+// Fiber, TagIP is fiber.Ctx.IP, and Config.Skipper and Config.RouteResolver
+// are ignored. Like zerologger.New, problems in the config are ignored, use
+// NewE to get them as an error.
+//
+// Handlers find the per-request logger and the request ID in the user
+// context, e.g. zerolog.Ctx(c.UserContext()). Errors returned by the
+// handlers are passed to the error handler of Fiber before the request is
+// logged, like the Logger middleware of Fiber does.
+func New(config ...zerologger.Config) fiber.Handler {
+	return Handler(zerologger.NewLenientMiddleware(config...))
+}
+
+// NewE is like New, but returns an error instead of ignoring problems in the
+// config, such as unknown tags or an invalid TimeZone. See
+// zerologger.Config.Validate.
+func NewE(config ...zerologger.Config) (fiber.Handler, error) {
+	m, err := zerologger.NewMiddleware(config...)
+	if err != nil {
+		return nil, err
+	}
+	return Handler(m), nil
+}
+
+// Handler returns the Fiber middleware of m, so its timestamp goroutine can
+// be stopped with Close.
+func Handler(m *zerologger.Middleware) fiber.Handler {
+	pool := sync.Pool{
+		New: func() interface{} {
+			return new(fiberContext)
+		},
+	}
+
+	return func(c *fiber.Ctx) error {
+		fc := pool.Get().(*fiberContext)
+		fc.ctx = c
+
+		m.Serve(fc)
+
+		*fc = fiberContext{}
+		pool.Put(fc)
+		return nil
+	}
+}
+
+// fiberContext is the zerologger.Adapter of Fiber. The request is read in
+// place, without a net/http copy.
+type fiberContext struct {
+	ctx *fiber.Ctx
+
+	// w is the writer set by the middleware to capture the response body.
+	// Fiber sends the response itself, so it is replayed into w once the
+	// handlers are done.
+	w http.ResponseWriter
+}
+
+var _ zerologger.Adapter = (*fiberContext)(nil)
+
+func (c *fiberContext) Method() string {
+	return c.ctx.Method()
+}
+
+func (c *fiberContext) Scheme() string {
+	return c.ctx.Protocol()
+}
+
+func (c *fiberContext) Host() string {
+	return string(c.ctx.Request().Host())
+}
+
+func (c *fiberContext) Path() string {
+	return c.ctx.Path()
+}
+
+func (c *fiberContext) URL() string {
+	return c.ctx.OriginalURL()
+}
+
+func (c *fiberContext) RequestURI() string {
+	return c.ctx.OriginalURL()
+}
+
+func (c *fiberContext) Proto() string {
+	return string(c.ctx.Request().Header.Protocol())
+}
+
+func (c *fiberContext) Header(name string) string {
+	return string(c.ctx.Request().Header.Peek(name))
+}
+
+func (c *fiberContext) RealIP() string {
+	return c.ctx.IP()
+}
+
+func (c *fiberContext) Route() string {
+	return c.ctx.Route().Path
+}
+
+func (c *fiberContext) Param(name string) string {
+	return c.ctx.Params(name)
+}
+
+func (c *fiberContext) QueryString() string {
+	return string(c.ctx.Request().URI().QueryString())
+}
+
+func (c *fiberContext) QueryParam(name string) string {
+	return string(c.ctx.Request().URI().QueryArgs().Peek(name))
+}
+
+func (c *fiberContext) FormValue(name string) string {
+	return string(c.ctx.Context().FormValue(name))
+}
+
+func (c *fiberContext) Cookie(name string) string {
+	return string(c.ctx.Request().Header.Cookie(name))
+}
+
+func (c *fiberContext) Get(key string) interface{} {
+	return c.ctx.Locals(key)
+}
+
+func (c *fiberContext) Context() context.Context {
+	return c.ctx.UserContext()
+}
+
+func (c *fiberContext) Status() int {
+	return c.ctx.Response().StatusCode()
+}
+
+// Size returns the length of the response body. Streamed bodies are not
+// read, and count as empty.
+func (c *fiberContext) Size() int64 {
+	if c.ctx.Response().IsBodyStream() {
+		return 0
+	}
+	return int64(len(c.ctx.Response().Body()))
+}
+
+func (c *fiberContext) ResponseHeader(name string) string {
+	return string(c.ctx.Response().Header.Peek(name))
+}
+
+func (c *fiberContext) Set(key string, value interface{}) {
+	c.ctx.Locals(key, value)
+}
+
+func (c *fiberContext) SetRequestHeader(name, value string) {
+	c.ctx.Request().Header.Set(name, value)
+}
+
+func (c *fiberContext) SetResponseHeader(name, value string) {
+	c.ctx.Response().Header.Set(name, value)
+}
+
+func (c *fiberContext) SetContext(ctx context.Context) {
+	c.ctx.SetUserContext(ctx)
+}
+
+// Body returns a reader of the request body, which Fiber has read already.
+func (c *fiberContext) Body() io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(c.ctx.Body()))
+}
+
+// SetBody does nothing, since Fiber handlers read the body of fiber.Ctx.
+// The reader of Body still sees the complete body.
+func (c *fiberContext) SetBody(io.ReadCloser) {}
+
+// Writer returns a writer that discards everything, for the middleware to
+// wrap. The response is replayed into the wrapper.
+func (c *fiberContext) Writer() http.ResponseWriter {
+	return &discardWriter{header: http.Header{}}
+}
+
+func (c *fiberContext) SetWriter(w http.ResponseWriter) {
+	if _, ok := w.(*discardWriter); ok {
+		w = nil
+	}
+	c.w = w
+}
+
+func (c *fiberContext) Next() error {
+	err := c.ctx.Next()
+	if err == nil {
+		c.replay()
+	}
+	return err
+}
+
+// Error lets the error handler of Fiber write the response.
+func (c *fiberContext) Error(err error) {
+	if err := c.ctx.App().Config().ErrorHandler(c.ctx, err); err != nil {
+		_ = c.ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+	c.replay()
+}
+
+// replay writes the response of Fiber into the writer of the middleware,
+// so its body can be captured. Streamed bodies are not read.
+func (c *fiberContext) replay() {
+	if c.w == nil {
+		return
+	}
+	res := c.ctx.Response()
+	c.w.Header().Set(fiber.HeaderContentType, string(res.Header.ContentType()))
+	c.w.WriteHeader(res.StatusCode())
+	if !res.IsBodyStream() {
+		_, _ = c.w.Write(res.Body())
+	}
+}
+
+// discardWriter is the http.ResponseWriter of the middleware. Fiber sends
+// the actual response, so everything written to it is discarded.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(int) {}
@@ -0,0 +1,130 @@
+package zerologgerfiber_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"czechia.dev/zerologger"
+	. "czechia.dev/zerologger/zerologgerfiber"
+)
+
+func testFiber(cfg zerologger.Config) (*bytes.Buffer, *fiber.App) {
+	buf := new(bytes.Buffer)
+	cfg.Output = buf
+	app := fiber.New()
+	app.Use(New(cfg))
+	return buf, app
+}
+
+func Test_New(t *testing.T) {
+	buf, app := testFiber(zerologger.Config{
+		Format: []string{
+			zerologger.TagMethod, zerologger.TagRoute, zerologger.TagStatus, zerologger.TagBytesSent,
+			zerologger.TagID, zerologger.TagIP, zerologger.TagBody, zerologger.TagResBody,
+		},
+		ContextFormat:       []string{zerologger.TagID},
+		EnableContextLogger: true,
+		RequestIDGenerator:  func() string { return "generated" },
+	})
+
+	app.Post("/users/:id", func(c *fiber.Ctx) error {
+		zerolog.Ctx(c.UserContext()).Info().Msg("handler")
+		require.Equal(t, "generated", zerologger.RequestIDFromContext(c.UserContext()))
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"id": c.Params("id")})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name":"me"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	res, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	require.Equal(t, "generated", res.Header.Get("X-Request-ID"))
+	body, _ := io.ReadAll(res.Body)
+	require.Equal(t, `{"id":"1"}`, string(body))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"id":"generated"`)
+	require.Contains(t, lines[1], `"method":"POST",`+
+		`"route":"/users/:id",`+
+		`"status":201,`+
+		`"bytesSent":10,`+
+		`"id":"generated",`+
+		`"ip":"0.0.0.0",`+
+		`"body":{"name":"me"},`+
+		`"resBody":{"id":"1"}`)
+}
+
+func Test_New_Error(t *testing.T) {
+	buf, app := testFiber(zerologger.Config{Format: []string{zerologger.TagStatus, zerologger.TagError}})
+
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return fiber.NewError(http.StatusTeapot, "teapot")
+	})
+	app.Get("/failed", func(c *fiber.Ctx) error {
+		return errors.New("failed")
+	})
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/error", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTeapot, res.StatusCode)
+	require.Contains(t, buf.String(), `"level":"warn","status":418,"error":"teapot"`)
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/failed", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	require.Contains(t, buf.String(), `"level":"error","status":500,"error":"failed"`)
+}
+
+func Test_New_Recover(t *testing.T) {
+	buf, app := testFiber(zerologger.Config{Format: []string{zerologger.TagStatus}, Recover: true})
+
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	require.Contains(t, buf.String(), `"status":500,"panic":"boom"`)
+}
+
+func Test_Handler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	m, err := zerologger.NewMiddleware(zerologger.Config{Format: []string{zerologger.TagTime, zerologger.TagStatus}, Output: buf})
+	require.NoError(t, err)
+	defer m.Close()
+
+	app := fiber.New()
+	app.Use(Handler(m))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `"status":200`)
+}
+
+func Test_NewE(t *testing.T) {
+	_, err := NewE(zerologger.Config{TimeZone: "invalid"})
+	require.Error(t, err)
+
+	buf, app := testFiber(zerologger.Config{Format: []string{zerologger.TagStatus}, TimeZone: "invalid"})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `"status":200`)
+}
@@ -0,0 +1,242 @@
+// Package zerologgergin adapts the zerologger middleware to Gin, so Gin
+// services log with the same Config and tags as Echo services.
+package zerologgergin
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"czechia.dev/zerologger"
+	"github.com/gin-gonic/gin"
+)
+
+// New creates a new zerolog middleware for Gin. TagRoute is the route of
+// Gin, TagIP is gin.Context.ClientIP, errors added with gin.Context.Error
+// are logged with TagError, and Config.Skipper and Config.RouteResolver
+// are ignored. Like zerologger.New, problems in the config are ignored, use
+// NewE to get them as an error.
+func New(config ...zerologger.Config) gin.HandlerFunc {
+	return Handler(zerologger.NewLenientMiddleware(config...))
+}
+
+// NewE is like New, but returns an error instead of ignoring problems in the
+// config, such as unknown tags or an invalid TimeZone. See
+// zerologger.Config.Validate.
+func NewE(config ...zerologger.Config) (gin.HandlerFunc, error) {
+	m, err := zerologger.NewMiddleware(config...)
+	if err != nil {
+		return nil, err
+	}
+	return Handler(m), nil
+}
+
+// Handler returns the Gin middleware of m, so its timestamp goroutine can
+// be stopped with Close.
+func Handler(m *zerologger.Middleware) gin.HandlerFunc {
+	pool := sync.Pool{
+		New: func() interface{} {
+			return new(ginContext)
+		},
+	}
+
+	return func(c *gin.Context) {
+		gc := pool.Get().(*ginContext)
+		gc.ctx = c
+
+		m.Serve(gc)
+
+		gc.ctx = nil
+		pool.Put(gc)
+	}
+}
+
+// ginContext is the zerologger.Adapter of Gin.
+type ginContext struct {
+	ctx *gin.Context
+}
+
+var _ zerologger.Adapter = (*ginContext)(nil)
+
+func (c *ginContext) Method() string {
+	return c.ctx.Request.Method
+}
+
+// Scheme returns the scheme of the connection, or of the headers set by
+// proxies.
+func (c *ginContext) Scheme() string {
+	r := c.ctx.Request
+	if r.TLS != nil {
+		return "https"
+	}
+	if scheme := r.Header.Get("X-Forwarded-Proto"); scheme != "" {
+		return scheme
+	}
+	return "http"
+}
+
+func (c *ginContext) Host() string {
+	return c.ctx.Request.Host
+}
+
+func (c *ginContext) Path() string {
+	return c.ctx.Request.URL.Path
+}
+
+func (c *ginContext) URL() string {
+	return c.ctx.Request.URL.String()
+}
+
+func (c *ginContext) RequestURI() string {
+	return c.ctx.Request.URL.RequestURI()
+}
+
+func (c *ginContext) Proto() string {
+	return c.ctx.Request.Proto
+}
+
+func (c *ginContext) Header(name string) string {
+	return c.ctx.Request.Header.Get(name)
+}
+
+func (c *ginContext) RealIP() string {
+	return c.ctx.ClientIP()
+}
+
+func (c *ginContext) Route() string {
+	return c.ctx.FullPath()
+}
+
+func (c *ginContext) Param(name string) string {
+	return c.ctx.Param(name)
+}
+
+func (c *ginContext) QueryString() string {
+	return c.ctx.Request.URL.RawQuery
+}
+
+func (c *ginContext) QueryParam(name string) string {
+	return c.ctx.Query(name)
+}
+
+func (c *ginContext) FormValue(name string) string {
+	return c.ctx.Request.FormValue(name)
+}
+
+func (c *ginContext) Cookie(name string) string {
+	v, _ := c.ctx.Cookie(name)
+	return v
+}
+
+func (c *ginContext) Get(key string) interface{} {
+	v, _ := c.ctx.Get(key)
+	return v
+}
+
+func (c *ginContext) Context() context.Context {
+	return c.ctx.Request.Context()
+}
+
+func (c *ginContext) Status() int {
+	return c.ctx.Writer.Status()
+}
+
+func (c *ginContext) Size() int64 {
+	if size := c.ctx.Writer.Size(); size > 0 {
+		return int64(size)
+	}
+	return 0
+}
+
+func (c *ginContext) ResponseHeader(name string) string {
+	return c.ctx.Writer.Header().Get(name)
+}
+
+func (c *ginContext) Set(key string, value interface{}) {
+	c.ctx.Set(key, value)
+}
+
+func (c *ginContext) SetRequestHeader(name, value string) {
+	c.ctx.Request.Header.Set(name, value)
+}
+
+func (c *ginContext) SetResponseHeader(name, value string) {
+	c.ctx.Writer.Header().Set(name, value)
+}
+
+// SetContext replaces the request, so handlers find the logger and the
+// request ID in the context of gin.Context.Request.
+func (c *ginContext) SetContext(ctx context.Context) {
+	c.ctx.Request = c.ctx.Request.WithContext(ctx)
+}
+
+func (c *ginContext) Body() io.ReadCloser {
+	return c.ctx.Request.Body
+}
+
+func (c *ginContext) SetBody(body io.ReadCloser) {
+	c.ctx.Request.Body = body
+}
+
+func (c *ginContext) Writer() http.ResponseWriter {
+	return c.ctx.Writer
+}
+
+// SetWriter sends the body written by the handlers through w. Gin keeps
+// track of the status, which it may change until the body is written.
+func (c *ginContext) SetWriter(w http.ResponseWriter) {
+	if gw, ok := w.(gin.ResponseWriter); ok {
+		c.ctx.Writer = gw
+		return
+	}
+	c.ctx.Writer = &responseWriter{ResponseWriter: c.ctx.Writer, w: w}
+}
+
+func (c *ginContext) Next() error {
+	c.ctx.Next()
+	if err := c.ctx.Errors.Last(); err != nil {
+		return err.Err
+	}
+	return nil
+}
+
+// Error responds with a 500 to errors that Gin does not know about, such
+// as recovered panics. Errors of gin.Context.Error are left to Gin.
+func (c *ginContext) Error(err error) {
+	for _, e := range c.ctx.Errors {
+		if e.Err == err {
+			return
+		}
+	}
+	if !c.ctx.Writer.Written() {
+		c.ctx.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// responseWriter is a gin.ResponseWriter that writes the body through w,
+// which wraps the original writer.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	return w.w.Write(b)
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.w.Write([]byte(s))
+}
+
+// Flush implements the http.Flusher interface.
+func (w *responseWriter) Flush() {
+	w.w.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.w.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,116 @@
+package zerologgergin_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"czechia.dev/zerologger"
+	. "czechia.dev/zerologger/zerologgergin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func testGin(cfg zerologger.Config) (*bytes.Buffer, *gin.Engine) {
+	buf := new(bytes.Buffer)
+	cfg.Output = buf
+	r := gin.New()
+	r.Use(New(cfg))
+	return buf, r
+}
+
+func Test_New(t *testing.T) {
+	buf, r := testGin(zerologger.Config{
+		Format:              []string{zerologger.TagMethod, zerologger.TagRoute, zerologger.TagStatus, zerologger.TagBytesSent, zerologger.TagID, zerologger.TagResBody},
+		ContextFormat:       []string{zerologger.TagRoute},
+		EnableContextLogger: true,
+		RequestIDGenerator:  func() string { return "generated" },
+	})
+
+	r.POST("/users/:id", func(c *gin.Context) {
+		zerolog.Ctx(c.Request.Context()).Info().Msg("handler")
+		c.Status(http.StatusOK)
+		c.JSON(http.StatusCreated, gin.H{"id": c.Param("id")})
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/users/1", nil))
+	require.Equal(t, http.StatusCreated, res.Code)
+	require.Equal(t, `{"id":"1"}`, res.Body.String())
+	require.Equal(t, "generated", res.Header().Get("X-Request-ID"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"route":"/users/:id"`)
+	require.Contains(t, lines[1], `"method":"POST",`+
+		`"route":"/users/:id",`+
+		`"status":201,`+
+		`"bytesSent":10,`+
+		`"id":"generated",`+
+		`"resBody":{"id":"1"}`)
+}
+
+func Test_New_Error(t *testing.T) {
+	buf, r := testGin(zerologger.Config{Format: []string{zerologger.TagStatus, zerologger.TagError}})
+
+	r.GET("/error", func(c *gin.Context) {
+		c.Error(errors.New("failed"))
+		c.AbortWithStatus(http.StatusBadRequest)
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/error", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, buf.String(), `"level":"warn","status":400,"error":"failed"`)
+}
+
+func Test_New_Recover(t *testing.T) {
+	buf, r := testGin(zerologger.Config{Format: []string{zerologger.TagStatus}, Recover: true})
+
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Contains(t, buf.String(), `"status":500,"panic":"boom"`)
+}
+
+func Test_Handler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	m, err := zerologger.NewMiddleware(zerologger.Config{Format: []string{zerologger.TagTime, zerologger.TagStatus}, Output: buf})
+	require.NoError(t, err)
+	defer m.Close()
+
+	r := gin.New()
+	r.Use(Handler(m))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, buf.String(), `"status":200`)
+}
+
+func Test_NewE(t *testing.T) {
+	_, err := NewE(zerologger.Config{TimeZone: "invalid"})
+	require.Error(t, err)
+
+	buf, r := testGin(zerologger.Config{Format: []string{zerologger.TagStatus}, TimeZone: "invalid"})
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Contains(t, buf.String(), `"status":200`)
+}
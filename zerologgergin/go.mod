@@ -0,0 +1,12 @@
+module czechia.dev/zerologger/zerologgergin
+
+go 1.16
+
+require (
+	czechia.dev/zerologger v0.1.0
+	github.com/gin-gonic/gin v1.7.7
+	github.com/rs/zerolog v1.23.0
+	github.com/stretchr/testify v1.7.0
+)
+
+replace czechia.dev/zerologger => ../
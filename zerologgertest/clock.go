@@ -0,0 +1,103 @@
+// Package zerologgertest provides utilities for testing code that uses the
+// zerologger middleware.
+package zerologgertest
+
+import (
+	"sync"
+	"time"
+
+	"czechia.dev/zerologger"
+)
+
+// FakeClock is a zerologger.Clock that only moves when told to, so that
+// TagTime and TagLatency produce exact, repeatable values.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker that ticks when the clock is advanced past its
+// next tick.
+func (c *FakeClock) NewTicker(d time.Duration) zerologger.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		c:       make(chan time.Time),
+		stopped: make(chan struct{}),
+		period:  d,
+		next:    c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Add advances the clock by d. Every running ticker that is due ticks once,
+// and Add blocks until each tick has been received.
+func (c *FakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due []*fakeTicker
+	for _, t := range c.tickers {
+		if t.due(now) {
+			due = append(due, t)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, t := range due {
+		select {
+		case t.c <- now:
+		case <-t.stopped:
+		}
+	}
+}
+
+// Set moves the clock to now, see Add.
+func (c *FakeClock) Set(now time.Time) {
+	c.Add(now.Sub(c.Now()))
+}
+
+type fakeTicker struct {
+	c        chan time.Time
+	stopped  chan struct{}
+	stopOnce sync.Once
+	period   time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+	})
+}
+
+// due reports whether the ticker should tick at now, and skips its next
+// tick past now. Missed ticks are dropped like time.Ticker does.
+func (t *fakeTicker) due(now time.Time) bool {
+	if t.period <= 0 || now.Before(t.next) {
+		return false
+	}
+	for !now.Before(t.next) {
+		t.next = t.next.Add(t.period)
+	}
+	return true
+}
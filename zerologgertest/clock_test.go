@@ -0,0 +1,34 @@
+package zerologgertest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"czechia.dev/zerologger/zerologgertest"
+)
+
+func Test_FakeClock(t *testing.T) {
+	now := time.Date(2021, time.August, 9, 13, 14, 15, 0, time.UTC)
+	clock := zerologgertest.NewFakeClock(now)
+	require.Equal(t, now, clock.Now())
+
+	ticker := clock.NewTicker(time.Second)
+	ticks := make(chan time.Time, 1)
+	go func() {
+		ticks <- <-ticker.C()
+	}()
+
+	// Not due yet, must not block
+	clock.Add(500 * time.Millisecond)
+	require.Equal(t, now.Add(500*time.Millisecond), clock.Now())
+
+	clock.Add(3 * time.Second)
+	require.Equal(t, now.Add(3500*time.Millisecond), <-ticks)
+
+	// Stopped tickers must not block
+	ticker.Stop()
+	clock.Set(now.Add(time.Hour))
+	require.Equal(t, now.Add(time.Hour), clock.Now())
+}